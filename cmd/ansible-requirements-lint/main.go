@@ -1,41 +1,154 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
 	"sync"
 
+	"github.com/atosatto/ansible-requirements-lint/pkg/cache"
+	"github.com/atosatto/ansible-requirements-lint/pkg/config"
+	"github.com/atosatto/ansible-requirements-lint/pkg/fixer"
 	"github.com/atosatto/ansible-requirements-lint/pkg/linter"
+	"github.com/atosatto/ansible-requirements-lint/pkg/lockfile"
 	"github.com/atosatto/ansible-requirements-lint/pkg/parser"
 	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
 	"github.com/atosatto/ansible-requirements-lint/pkg/writer"
+	goversion "github.com/hashicorp/go-version"
 )
 
 var (
 	verbose      = flag.Bool("v", false, "")
-	galaxyURL    = flag.String("galaxy", provider.DefaultAnsibleGalaxyURL, "")
+	gitCreds     = flag.String("git-credentials", config.DefaultGitCredentialsPath(), "")
 	noColor      = flag.Bool("no-color", false, "")
 	outFormat    = flag.String("o", "text", "")
+	parallelism  = flag.Int("j", linter.DefaultParallelism, "")
+	galaxyJobs   = flag.Int("galaxy-j", linter.DefaultGalaxyConcurrency, "")
+	gitJobs      = flag.Int("git-j", linter.DefaultGitConcurrency, "")
+	deps         = flag.Bool("deps", false, "")
+	fix          = flag.Bool("fix", false, "")
+	fixLevel     = flag.String("fix-level", "major", "")
+	dryRun       = flag.Bool("dry-run", false, "")
+	noCache      = flag.Bool("no-cache", false, "")
+	cacheDir     = flag.String("cache-dir", "", "")
+	cacheTTL     = flag.Duration("cache-ttl", cache.DefaultTTL, "")
+	offline      = flag.Bool("offline", false, "")
+	snapshot     = flag.String("snapshot", "", "")
+	lockPath     = flag.String("lockfile", "", "")
+	pinning      = flag.String("pinning", linter.DefaultPinningPolicy.String(), "")
+	policyPath   = flag.String("policy", config.DefaultUpdatePolicyPath, "")
+	updateLevel  = flag.String("update-level", "", "")
+	preReleases  = flag.Bool("pre-releases", false, "")
 	printVersion = flag.Bool("V", false, "")
 	printHelp    = flag.Bool("h", false, "")
 )
 
+var (
+	galaxyURLs     stringsFlag
+	galaxyTokens   stringsFlag
+	galaxyAuthURLs stringsFlag
+)
+
+func init() {
+	flag.Var(&galaxyURLs, "galaxy", "")
+	flag.Var(&galaxyTokens, "galaxy-token", "")
+	flag.Var(&galaxyAuthURLs, "galaxy-auth-url", "")
+}
+
+// stringsFlag is a flag which can
+// be set multiple times.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
 // version will be set at compilation time
 var version string
 
 var usage = fmt.Sprintf(`Usage: ansible-requirements-lint [options...] <requirements-file>
+       ansible-requirements-lint [options...] lock <requirements-file>
+       ansible-requirements-lint [options...] verify-lock <requirements-file>
+       ansible-requirements-lint [options...] cache <clear|export>
+
+Commands:
+  lock           Resolve the roles and collections to exact versions, together
+                 with their Git commit or archive checksum, and write them to
+                 the lock file.
+  verify-lock    Check that the roles and collections are locked, and that
+                 their locked versions have not been changed upstream.
+  cache clear    Remove the versions stored in the cache directory.
+  cache export   Print the versions stored in the cache directory
+                 as a snapshot to be used with -snapshot.
 
 Options:
   -v             Enable verbose output.
-  -galaxy <url>  Set the Ansible Galaxy URL (default: %s).
-  -o <format>    Format of the output, allowed values are text,table (default: text).
+  -galaxy <url>  Set the Ansible Galaxy URL (default: the servers configured
+                 in ansible.cfg, or %s).
+                 Can be repeated to query multiple servers in order.
+  -galaxy-token <t>
+                 Set the API token of the Ansible Galaxy server, the n-th
+                 token is used for the n-th server (default: the token
+                 stored in the token_path of ansible.cfg when a single
+                 server is configured).
+  -galaxy-auth-url <url>
+                 Exchange the token for access tokens issued by the given
+                 SSO server, as needed by Red Hat Automation Hub, the n-th
+                 URL is used for the n-th server.
+  -git-credentials <f>
+                 Read the credentials used to access private Git repositories
+                 from the given file (default: %s).
+  -j <n>         Number of roles and collections checked concurrently (default: %d).
+  -galaxy-j <n>  Maximum number of concurrent requests to Ansible Galaxy (default: %d).
+  -git-j <n>     Maximum number of concurrent requests to Git repositories (default: %d).
+  -deps          Also check the transitive dependencies of the roles, declared
+                 in their meta/main.yml and meta/requirements.yml files, and
+                 report the roles required at conflicting versions.
+  -o <format>    Format of the output, allowed values are
+                 text,table,json,jsonl,sarif,junit,checkstyle,
+                 codequality,github (default: text).
   -no-color      Disable color output.
+  -policy <f>    Read the update policy, holding the version constraints of the
+                 roles and collections, from the given file (default: %s).
+  -update-level <l>
+                 Only report updates up to the given version increment, more
+                 recent versions are reported as info, allowed values are
+                 patch,minor,major (default: the updates of the policy, or major).
+  -pre-releases  Report updates to pre-release versions, ignored by default.
+  -fix           Update the requirements files in place to the latest versions.
+  -fix-level <l> Only apply updates up to the given version increment,
+                 allowed values are patch,minor,major (default: major).
+  -dry-run       Print the changes -fix would apply as a unified diff
+                 instead of updating the requirements files, only
+                 allowed with the text output format.
+  -no-cache      Do not read or write the versions cache.
+  -cache-dir <d> Directory storing the versions fetched from Ansible Galaxy
+                 and git repositories (default: %s).
+  -cache-ttl <t> Time the cached versions are considered fresh (default: %s).
+  -offline       Never query Ansible Galaxy and git repositories, only use
+                 the versions stored in the cache or in the snapshot.
+  -snapshot <f>  Load the versions of roles and collections from the given
+                 snapshot file, as printed by cache export.
+  -pinning <p>   Kinds of versions the roles are allowed to be pinned to,
+                 allowed values are semver-tag,tag,branch,commit,
+                 short-commit,missing (default: %s).
+  -lockfile <f>  Path of the lock file written by lock and read by verify-lock
+                 (default: the requirements file with the .lock extension).
   -V             Print the version number and exit.
   -h             Show this help message and exit.
-`, provider.DefaultAnsibleGalaxyURL)
+`, provider.DefaultAnsibleGalaxyURL, config.DefaultGitCredentialsPath(), linter.DefaultParallelism, linter.DefaultGalaxyConcurrency, linter.DefaultGitConcurrency, config.DefaultUpdatePolicyPath, defaultCacheDir(), cache.DefaultTTL, linter.DefaultPinningPolicy)
 
 func main() {
 	flag.Usage = func() {
@@ -49,12 +162,138 @@ func main() {
 		errAndExit(fmt.Sprintf("ansible-galaxy-lint v%s", version))
 	}
 
-	if flag.NArg() != 1 || *printHelp {
+	if *printHelp {
 		usageAndExit("")
 	}
 
+	// manage the cache
+	if flag.NArg() > 0 && flag.Arg(0) == "cache" {
+		if flag.NArg() != 2 {
+			usageAndExit("")
+		}
+		if *noCache {
+			usageAndExit(fmt.Sprintf("cache %s can not be used with -no-cache", flag.Arg(1)))
+		}
+		switch flag.Arg(1) {
+		case "clear":
+			if err := newCache().Clear(); err != nil {
+				errAndExit(fmt.Sprintf("unable to clear the cache: %s", err))
+			}
+		case "export":
+			if err := newCache().WriteSnapshot(os.Stdout); err != nil {
+				errAndExit(fmt.Sprintf("unable to export the cache: %s", err))
+			}
+		default:
+			usageAndExit("")
+		}
+		os.Exit(0)
+	}
+
+	// lock the requirements, or verify the lock file
+	var command, requirementsFile string
+	switch {
+	case flag.NArg() == 2 && (flag.Arg(0) == "lock" || flag.Arg(0) == "verify-lock"):
+		command, requirementsFile = flag.Arg(0), flag.Arg(1)
+	case flag.NArg() == 1:
+		requirementsFile = flag.Arg(0)
+	default:
+		usageAndExit("")
+	}
+	if len(requirementsFile) == 0 {
+		usageAndExit("")
+	}
+
+	if len(command) != 0 && (*offline || *fix || *dryRun) {
+		usageAndExit(fmt.Sprintf("%s can not be used with -offline, -fix and -dry-run", command))
+	}
+
+	if *dryRun && *outFormat != "text" {
+		// the diff would be mixed with the machine readable output
+		usageAndExit(fmt.Sprintf("-dry-run can not be used with -o %s", *outFormat))
+	}
+
+	if *offline && *noCache && len(*snapshot) == 0 {
+		usageAndExit("-offline requires either the cache or a snapshot")
+	}
+
+	versionsCache := newCache()
+	if len(*snapshot) != 0 {
+		if err := versionsCache.LoadSnapshot(*snapshot); err != nil {
+			errAndExit(fmt.Sprintf("unable to load the snapshot: %s", err))
+		}
+	}
+	if *offline {
+		versionsCache.WithOffline()
+	}
+
+	// read the Ansible configuration
+	cfg, err := config.Load()
+	if err != nil {
+		errAndExit(fmt.Sprintf("unable to read the Ansible configuration: %s", err))
+	}
+
+	// configure the Ansible Galaxy servers
+	servers, err := galaxyServers(cfg)
+	if err != nil {
+		usageAndExit(err.Error())
+	}
+
+	// configure the credentials of the Git servers
+	credentials, err := config.LoadGitCredentials(*gitCreds, isSet("git-credentials"))
+	if err != nil {
+		errAndExit(fmt.Sprintf("unable to read the Git credentials: %s", err))
+	}
+
+	maxBump, err := linter.ParseBump(*fixLevel)
+	if err != nil {
+		usageAndExit(err.Error())
+	}
+
+	// read the update policy, overridden by the flags
+	updatePolicy, err := loadUpdatePolicy(*policyPath, isSet("policy"))
+	if err != nil {
+		errAndExit(fmt.Sprintf("unable to read the update policy: %s", err))
+	}
+	if len(*updateLevel) != 0 {
+		if updatePolicy.MaxBump, err = linter.ParseBump(*updateLevel); err != nil {
+			usageAndExit(err.Error())
+		}
+	}
+	if *preReleases {
+		updatePolicy.PreReleases = true
+	}
+
+	pinningPolicy, err := linter.ParsePinningPolicy(*pinning)
+	if err != nil {
+		usageAndExit(err.Error())
+	}
+
 	var out writer.Writer
 	switch *outFormat {
+	case "json":
+		out = writer.JSONWriter{}
+	case "jsonl":
+		out = writer.JSONLinesWriter{}
+	case "sarif":
+		out = writer.SARIFWriter{
+			Verbose: *verbose,
+			Version: version,
+		}
+	case "junit":
+		out = writer.JUnitWriter{}
+	case "checkstyle":
+		out = writer.CheckstyleWriter{
+			Verbose: *verbose,
+		}
+	case "codequality":
+		out = writer.CodeQualityWriter{
+			Verbose: *verbose,
+		}
+	case "github":
+		out = writer.GitHubWriter{
+			Verbose:     *verbose,
+			StepSummary: os.Getenv("GITHUB_STEP_SUMMARY"),
+		}
 	case "table":
 		out = writer.TableWriter{
 			Verbose: *verbose,
@@ -66,11 +305,6 @@ func main() {
 		}
 	}
 
-	var requirementsFile = flag.Arg(0)
-	if len(requirementsFile) == 0 {
-		usageAndExit("")
-	}
-
 	// handle Ctrl+C
 	ctx, cancel := context.WithCancel(context.Background())
 	c := make(chan os.Signal, 1)
@@ -103,54 +337,371 @@ func main() {
 		}
 	}
 
+	var lockFile = *lockPath
+	if len(lockFile) == 0 {
+		lockFile = lockfile.PathFor(requirementsFile)
+	}
+	// limit the concurrent requests sent to each
+	// provider by all the Linters as a whole
+	limiter := linter.NewLimiter(*galaxyJobs, *gitJobs)
+
+	if command == "lock" {
+		lockRequirements(ctx, newLocker(servers, credentials, limiter, updatePolicy), requirements, lockFile, out)
+		return
+	}
+
 	// create a WaitGroup to make sure
 	// to wait for the Linters to finish before
 	// exiting the program
 	var wg sync.WaitGroup
 
-	// whether to exit with an error code
-	// or not
-	var exitWithError = false
+	// results returned by the Linters
+	var results []linter.Result
 
-	// run the Updates Linter
+	// configure the Linters
+	updatesLinter := linter.NewUpdatesLinter()
+	updatesLinter.WithGalaxyServers(servers...)
+	updatesLinter.WithGitCredentials(credentials...)
+	updatesLinter.WithParallelism(*parallelism)
+	updatesLinter.WithLimiter(limiter)
+	updatesLinter.WithCache(versionsCache)
+	updatesLinter.WithUpdatePolicy(updatePolicy)
+	var linters = []linter.Linter{updatesLinter}
+	if command == "verify-lock" {
+		lock, err := lockfile.Read(lockFile)
+		if err != nil {
+			errAndExit(fmt.Sprintf("unable to read the lock file: %s", err))
+		}
+		linters = []linter.Linter{linter.NewLockLinter(newLocker(servers, credentials, limiter, updatePolicy), lock)}
+	} else {
+		if *deps {
+			// the Linters share the resolver, so that
+			// each role is only fetched once
+			resolver := dependencyResolver(cfg, servers, credentials, limiter, updatePolicy)
+			updatesLinter.WithDependencies(resolver)
+			conflictsLinter := linter.NewConflictsLinter(resolver)
+			conflictsLinter.WithParallelism(*parallelism)
+			linters = append(linters, conflictsLinter)
+		}
+		pinningLinter := linter.NewPinningLinter(pinningPolicy)
+		pinningLinter.WithGalaxyServers(servers...)
+		pinningLinter.WithGitCredentials(credentials...)
+		pinningLinter.WithParallelism(*parallelism)
+		pinningLinter.WithLimiter(limiter)
+		pinningLinter.WithCache(versionsCache)
+		if *offline {
+			updatesLinter.WithOffline()
+			pinningLinter.WithOffline()
+		}
+		linters = append(linters, pinningLinter)
+		if !*offline {
+			linters = append(linters, movedTagsLinter(lockFile, credentials, limiter))
+		}
+	}
+
+	// run the Linters one after the other
 	wg.Add(2)
-	updatesLinterResults := make(chan linter.Result)
-	updatesLinterOutput := make(chan linter.Result)
+	lintersResults := make(chan linter.Result)
+	lintersOutput := make(chan linter.Result)
 	go func() {
-		updatesLinter := linter.NewUpdatesLinter()
-		updatesLinter.WithAnsibleGalaxyURL(*galaxyURL)
-		updatesLinter.Lint(ctx, requirements, updatesLinterResults)
 		defer wg.Done()
+		runLinters(ctx, linters, requirements, lintersResults)
 	}()
 	go func() {
-		out.WriteUpdates(ctx, os.Stdout, updatesLinterOutput)
+		out.WriteUpdates(ctx, os.Stdout, lintersOutput)
 		defer wg.Done()
 	}()
 
-	// check wether the Updates Linter has reported
+	// check wether the Linters have reported
 	// any Error or Warning and copy back the results
 	// to the output channel
 	func() {
-		for update := range updatesLinterResults {
+		for res := range lintersResults {
 			select {
 			case <-ctx.Done():
 				return
 			default:
-				if update.Level != linter.LevelInfo {
-					exitWithError = true
-				}
-				updatesLinterOutput <- update
+				results = append(results, res)
+				lintersOutput <- res
 			}
 		}
 	}()
-	close(updatesLinterOutput)
+	close(lintersOutput)
 
 	// wait for the Linters to be done
 	wg.Wait()
 
-	if exitWithError {
+	// apply the updates to the requirements files
+	var fixed = make(map[types.Position]bool)
+	if *fix || *dryRun {
+		fixed = applyFixes(results, maxBump, *dryRun)
+	}
+
+	// exit with an error code if any Error or Warning
+	// has been reported and not fixed
+	for _, res := range results {
+		if res.Level != linter.LevelInfo && !isFixed(res, fixed) {
+			os.Exit(1)
+		}
+	}
+}
+
+// isFixed checks whether res is an update applied by applyFixes.
+// The results of the other Linters are never fixed, even when
+// they refer to a role or collection which has been updated.
+func isFixed(res linter.Result, fixed map[types.Position]bool) bool {
+	update, ok := res.Metadata.(linter.Update)
+	return ok && update.IsUpdate && fixed[res.Position()]
+}
+
+// runLinters runs the given Linters one after the other, sending
+// all their results on the output channel, which is closed on exit.
+func runLinters(ctx context.Context, linters []linter.Linter, requirements *types.Requirements, output chan<- linter.Result) {
+	defer close(output)
+	for _, l := range linters {
+		results := make(chan linter.Result)
+		go l.Lint(ctx, requirements, results)
+		for res := range results {
+			select {
+			case <-ctx.Done():
+				// drain the results, so that the
+				// Linter is not blocked sending them
+				for range results {
+				}
+				return
+			case output <- res:
+			}
+		}
+	}
+}
+
+// lockRequirements locks the given requirements, writing the lock file
+// at path, and writes the roles and collections which cannot be locked
+// with the given Writer. The lock file is not written if any of them
+// cannot be locked because of an error.
+func lockRequirements(ctx context.Context, locker *linter.Locker, requirements *types.Requirements, path string, out writer.Writer) {
+	lock, results := locker.Lock(ctx, requirements)
+	if ctx.Err() != nil {
 		os.Exit(1)
 	}
+
+	output := make(chan linter.Result)
+	go func() {
+		defer close(output)
+		for _, res := range results {
+			output <- res
+		}
+	}()
+	out.WriteUpdates(ctx, os.Stdout, output)
+
+	for _, res := range results {
+		if res.Level == linter.LevelError {
+			errAndExit(fmt.Sprintf("unable to lock the requirements, %s has not been written", path))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := lock.Write(&buf); err != nil {
+		errAndExit(fmt.Sprintf("unable to write %s: %s", path, err))
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		errAndExit(fmt.Sprintf("unable to write %s: %s", path, err))
+	}
+}
+
+// applyFixes updates the requirements files with the updates found
+// in results, returning the positions of the fixed roles and collections.
+// If dryRun is true, the changes are printed as a unified diff instead.
+func applyFixes(results []linter.Result, maxBump linter.Bump, dryRun bool) map[types.Position]bool {
+	var fixed = make(map[types.Position]bool)
+
+	var fixes = fixer.FixesFromResults(results, maxBump)
+	var files = make([]string, 0, len(fixes))
+	for f := range fixes {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			errAndExit(fmt.Sprintf("unable to open %s: %s", f, err))
+		}
+		content, err := ioutil.ReadFile(f)
+		if err != nil {
+			errAndExit(fmt.Sprintf("unable to open %s: %s", f, err))
+		}
+
+		updated, err := fixer.Apply(content, fixes[f])
+		if err != nil {
+			errAndExit(fmt.Sprintf("unable to update %s: %s", f, err))
+		}
+
+		if dryRun {
+			fmt.Fprint(os.Stdout, fixer.UnifiedDiff(f, content, updated))
+			continue
+		}
+
+		if err := ioutil.WriteFile(f, updated, info.Mode()); err != nil {
+			errAndExit(fmt.Sprintf("unable to update %s: %s", f, err))
+		}
+		for _, fix := range fixes[f] {
+			fixed[fix.Result.Position()] = true
+		}
+	}
+
+	return fixed
+}
+
+// galaxyServers returns the Ansible Galaxy servers configured by
+// the command line flags or, if no -galaxy flag has been set,
+// by the Ansible configuration.
+func galaxyServers(cfg *config.Config) ([]provider.GalaxyServer, error) {
+	if len(galaxyURLs) == 0 {
+		if len(galaxyTokens) != 0 || len(galaxyAuthURLs) != 0 {
+			return nil, fmt.Errorf("-galaxy-token and -galaxy-auth-url require -galaxy to be set")
+		}
+		return cfg.Servers()
+	}
+	if len(galaxyTokens) > len(galaxyURLs) || len(galaxyAuthURLs) > len(galaxyURLs) {
+		return nil, fmt.Errorf("-galaxy-token and -galaxy-auth-url can not be set more times than -galaxy")
+	}
+
+	var servers = make([]provider.GalaxyServer, len(galaxyURLs))
+	for i, u := range galaxyURLs {
+		servers[i].URL = u
+		if i < len(galaxyTokens) {
+			servers[i].Token = galaxyTokens[i]
+		}
+		if i < len(galaxyAuthURLs) {
+			servers[i].AuthURL = galaxyAuthURLs[i]
+		}
+	}
+
+	// as ansible-galaxy does, the token stored by
+	// ansible-galaxy login is only used when a
+	// single server is configured
+	if len(servers) == 1 && len(servers[0].Token) == 0 {
+		token, err := config.ReadGalaxyToken(cfg.GalaxyTokenPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the Ansible Galaxy token: %s", err)
+		}
+		servers[0].Token = token
+	}
+	return servers, nil
+}
+
+// isSet checks whether the flag with the
+// given name has been set on the command line.
+func isSet(name string) bool {
+	var set bool
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// loadUpdatePolicy returns the UpdatePolicy read from the file at path,
+// or the DefaultUpdatePolicy for the settings the file does not set.
+// The file is required to exist if required is true.
+func loadUpdatePolicy(path string, required bool) (linter.UpdatePolicy, error) {
+	var policy = linter.DefaultUpdatePolicy
+
+	f, err := config.LoadUpdatePolicy(path, required)
+	if err != nil {
+		return policy, err
+	}
+	if len(f.Updates) != 0 {
+		if policy.MaxBump, err = linter.ParseBump(f.Updates); err != nil {
+			return policy, fmt.Errorf("unable to parse %s: %v", path, err)
+		}
+	}
+	policy.PreReleases = f.PreReleases
+	if len(f.Constraints) != 0 {
+		policy.Constraints = make(map[string]goversion.Constraints)
+	}
+	for name, c := range f.Constraints {
+		constraints, err := linter.ParseConstraints(c)
+		if err != nil {
+			return policy, fmt.Errorf("unable to parse %s: invalid constraints %s for %s: %v", path, c, name, err)
+		}
+		policy.Constraints[name] = constraints
+	}
+	policy.TagPrefixes = f.TagPrefixes
+	return policy, nil
+}
+
+// dependencyResolver returns the DependencyResolver fetching the roles from
+// the given Ansible Galaxy servers and Git repositories, unless they are
+// installed in the roles path configured by the Ansible configuration.
+func dependencyResolver(cfg *config.Config, servers []provider.GalaxyServer, credentials []provider.GitCredentials, limiter *linter.Limiter, policy linter.UpdatePolicy) *linter.DependencyResolver {
+	resolver := linter.NewDependencyResolver()
+	resolver.WithGalaxyServers(servers...)
+	resolver.WithGitCredentials(credentials...)
+	resolver.WithLimiter(limiter)
+	resolver.WithUpdatePolicy(policy)
+	resolver.WithRolesPaths(cfg.RolesPath...)
+	if *offline {
+		resolver.WithOffline()
+	}
+	return resolver
+}
+
+// newLocker returns the Locker resolving the roles and collections
+// on the given Ansible Galaxy servers and Git repositories.
+func newLocker(servers []provider.GalaxyServer, credentials []provider.GitCredentials, limiter *linter.Limiter, policy linter.UpdatePolicy) *linter.Locker {
+	locker := linter.NewLocker()
+	locker.WithGalaxyServers(servers...)
+	locker.WithGitCredentials(credentials...)
+	locker.WithParallelism(*parallelism)
+	locker.WithLimiter(limiter)
+	locker.WithUpdatePolicy(policy)
+	return locker
+}
+
+// movedTagsLinter returns the MovedTagsLinter comparing the tags of the roles
+// with the commits recorded in their comments or in the lock file at path.
+// The lock file is optional, unless its path has been set by -lockfile.
+func movedTagsLinter(path string, credentials []provider.GitCredentials, limiter *linter.Limiter) *linter.MovedTagsLinter {
+	tagsLinter := linter.NewMovedTagsLinter()
+	tagsLinter.WithGitCredentials(credentials...)
+	tagsLinter.WithParallelism(*parallelism)
+	tagsLinter.WithLimiter(limiter)
+
+	lock, err := lockfile.Read(path)
+	switch {
+	case err == nil:
+		tagsLinter.WithLockfile(lock)
+	case !os.IsNotExist(err) || len(*lockPath) != 0:
+		errAndExit(fmt.Sprintf("unable to read the lock file: %s", err))
+	}
+	return tagsLinter
+}
+
+// newCache returns the Cache configured by the command line flags.
+// When the cache is disabled, versions are only cached in memory
+// for the duration of the run.
+func newCache() *cache.Cache {
+	if *noCache {
+		return cache.New("", *cacheTTL)
+	}
+	var dir = *cacheDir
+	if len(dir) == 0 {
+		dir = defaultCacheDir()
+	}
+	return cache.New(dir, *cacheTTL)
+}
+
+// defaultCacheDir returns the default cache directory,
+// or the nil string if it cannot be determined.
+func defaultCacheDir() string {
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		return ""
+	}
+	return dir
 }
 
 func errAndExit(msg string) {
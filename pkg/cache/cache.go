@@ -0,0 +1,334 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTTL is the default amount of time
+	// cached entries are considered fresh.
+	DefaultTTL = time.Hour
+
+	// dirName is the name of the cache directory
+	// created in the user cache directory.
+	dirName = "ansible-requirements-lint"
+)
+
+// entryFile matches the names of the files
+// storing the entries in the cache directory.
+var entryFile = regexp.MustCompile(`^[0-9a-f]{64}\.json$`)
+
+// Key identifies a role or collection
+// in the cache of a provider.
+type Key struct {
+	// Provider is the name of the provider
+	// the versions have been fetched from.
+	Provider string `json:"provider"`
+
+	// Kind is either role or collection.
+	Kind string `json:"kind"`
+
+	// Name is the name of the collection.
+	Name string `json:"name,omitempty"`
+
+	// Source is the normalized source of the role,
+	// or the server hosting the collection.
+	Source string `json:"source,omitempty"`
+}
+
+// String converts a Key to string.
+func (k Key) String() string {
+	return strings.Join([]string{k.Provider, k.Kind, k.Name, k.Source}, "\x00")
+}
+
+// Entry is a cached list of versions.
+type Entry struct {
+	Key
+
+	// Versions is the list of versions
+	// fetched for the role or collection.
+	Versions []string `json:"versions"`
+
+	// Server is the name of the server
+	// the versions have been fetched from.
+	Server string `json:"server,omitempty"`
+
+	// FetchedAt is the time the versions have
+	// been fetched from the upstream provider.
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Cache stores the versions of roles and collections
+// fetched from the upstream providers, both in memory
+// and, if a directory is configured, on disk so that
+// they can be reused across runs.
+type Cache struct {
+	dir string
+	ttl time.Duration
+
+	// offline is true when the upstream providers
+	// must not be queried, and the versions must be
+	// served from the cache regardless of their age
+	offline bool
+
+	mu     sync.Mutex
+	memory map[Key]Entry
+
+	// now returns the current time, it is defined
+	// as attribute of the Cache to allow mocking
+	// during unit tests
+	now func() time.Time
+}
+
+// New creates a new Cache storing entries in dir for
+// the given ttl. If dir is the nil string, entries
+// are only stored in memory.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{
+		dir:    dir,
+		ttl:    ttl,
+		memory: make(map[Key]Entry),
+		now:    time.Now,
+	}
+}
+
+// DefaultDir returns the default cache directory,
+// located in the user cache directory
+// (e.g. $XDG_CACHE_HOME on Linux).
+func DefaultDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, dirName), nil
+}
+
+// WithOffline configures the Cache to never query the
+// upstream providers, serving the versions of roles and
+// collections from the cache even when they are stale.
+func (c *Cache) WithOffline() {
+	c.offline = true
+}
+
+// Get returns the versions cached for key, if any. Only entries
+// fetched less than the Cache TTL ago are returned.
+func (c *Cache) Get(key Key) ([]string, bool) {
+	e, ok := c.entry(key)
+	if !ok || c.isStale(e) {
+		return nil, false
+	}
+	return e.Versions, true
+}
+
+// Set stores the versions fetched for key in the Cache.
+func (c *Cache) Set(key Key, versions []string) error {
+	return c.store(Entry{Key: key, Versions: versions, FetchedAt: c.now()})
+}
+
+// store stores the entry e in the Cache.
+func (c *Cache) store(e Entry) error {
+	var key = e.Key
+
+	c.mu.Lock()
+	c.memory[key] = e
+	c.mu.Unlock()
+
+	if len(c.dir) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	// write the entry to a temporary file first
+	// so that concurrent readers never observe
+	// a partially written entry
+	f, err := ioutil.TempFile(c.dir, "entry")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), c.path(key))
+}
+
+// Clear removes all the entries from the Cache. Only the files
+// storing the entries are removed from the cache directory, which
+// is left in place together with any other file it holds, as it
+// may be a directory shared with other tools.
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	c.memory = make(map[Key]Entry)
+	c.mu.Unlock()
+
+	if len(c.dir) == 0 {
+		return nil
+	}
+	files, err := ioutil.ReadDir(c.dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if !f.Mode().IsRegular() || !entryFile.MatchString(f.Name()) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.dir, f.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Entries returns all the entries stored in the Cache,
+// both in memory and on disk, sorted by key.
+func (c *Cache) Entries() ([]Entry, error) {
+	var entries = make(map[Key]Entry)
+
+	if len(c.dir) != 0 {
+		files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			e, err := readEntry(f)
+			if err != nil {
+				// ignore corrupted entries
+				continue
+			}
+			entries[e.Key] = e
+		}
+	}
+
+	c.mu.Lock()
+	for k, e := range c.memory {
+		entries[k] = e
+	}
+	c.mu.Unlock()
+
+	var list = make([]Entry, 0, len(entries))
+	for _, e := range entries {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key.String() < list[j].Key.String()
+	})
+	return list, nil
+}
+
+// WriteSnapshot writes all the entries stored
+// in the Cache to w as a snapshot, that can be
+// loaded by LoadSnapshot.
+func (c *Cache) WriteSnapshot(w io.Writer) error {
+	entries, err := c.Entries()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// LoadSnapshot loads in memory the entries of the snapshot stored
+// in the file at path, as written by WriteSnapshot. Entries not
+// holding the time their versions have been fetched are considered
+// fetched at the last modification time of the snapshot file.
+// Entries already stored in the Cache are only replaced by the ones
+// of the snapshot if these have been fetched more recently.
+func (c *Cache) LoadSnapshot(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("unable to parse the snapshot %s: %v", path, err)
+	}
+
+	for _, e := range entries {
+		if e.FetchedAt.IsZero() {
+			e.FetchedAt = info.ModTime()
+		}
+		if cached, ok := c.entry(e.Key); ok && !cached.FetchedAt.Before(e.FetchedAt) {
+			continue
+		}
+		c.mu.Lock()
+		c.memory[e.Key] = e
+		c.mu.Unlock()
+	}
+	return nil
+}
+
+// isStale checks whether the entry e has
+// been fetched more than the Cache TTL ago.
+func (c *Cache) isStale(e Entry) bool {
+	return c.now().Sub(e.FetchedAt) > c.ttl
+}
+
+// entry returns the entry stored for key, looking it
+// up in memory first, and then on disk.
+func (c *Cache) entry(key Key) (Entry, bool) {
+	c.mu.Lock()
+	e, ok := c.memory[key]
+	c.mu.Unlock()
+	if ok || len(c.dir) == 0 {
+		return e, ok
+	}
+
+	e, err := readEntry(c.path(key))
+	if err != nil || e.Key != key {
+		return Entry{}, false
+	}
+
+	c.mu.Lock()
+	c.memory[key] = e
+	c.mu.Unlock()
+	return e, true
+}
+
+// path returns the path of the file storing
+// the entry for key.
+func (c *Cache) path(key Key) string {
+	return filepath.Join(c.dir, fmt.Sprintf("%x.json", sha256.Sum256([]byte(key.String()))))
+}
+
+// readEntry reads the entry stored in the file at path.
+func readEntry(path string) (Entry, error) {
+	var e Entry
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return e, err
+	}
+	err = json.Unmarshal(data, &e)
+	return e, err
+}
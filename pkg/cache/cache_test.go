@@ -0,0 +1,183 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+// countingProvider is a RolesProvider counting
+// the number of lookups it has served.
+type countingProvider struct {
+	calls    int
+	versions []string
+}
+
+func (p *countingProvider) VersionsForRole(ctx context.Context, r types.Role) ([]string, error) {
+	p.calls++
+	return p.versions, nil
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "ansible-requirements-lint")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+	return dir
+}
+
+func TestCache(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	var now = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New(dir, time.Hour)
+	c.now = func() time.Time { return now }
+
+	var key = Key{Provider: "git", Kind: "role", Source: "https://github.com/atosatto/ansible-minio"}
+	if _, ok := c.Get(key); ok {
+		t.Fatalf("expected a cache miss on an empty cache")
+	}
+	if err := c.Set(key, []string{"v1.0.0", "v1.1.0"}); err != nil {
+		t.Fatalf("unable to write the cache: %v", err)
+	}
+	versions, ok := c.Get(key)
+	if !ok || !reflect.DeepEqual(versions, []string{"v1.0.0", "v1.1.0"}) {
+		t.Errorf("expected the cached versions, got %v (%t)", versions, ok)
+	}
+
+	// entries are persisted across runs
+	persisted := New(dir, time.Hour)
+	persisted.now = func() time.Time { return now.Add(30 * time.Minute) }
+	versions, ok = persisted.Get(key)
+	if !ok || !reflect.DeepEqual(versions, []string{"v1.0.0", "v1.1.0"}) {
+		t.Errorf("expected the versions cached on disk, got %v (%t)", versions, ok)
+	}
+
+	// entries older than the TTL are ignored
+	persisted.now = func() time.Time { return now.Add(2 * time.Hour) }
+	if _, ok := persisted.Get(key); ok {
+		t.Errorf("expected a cache miss on an expired entry")
+	}
+
+	// clearing the cache only removes the entries
+	var other = filepath.Join(dir, "other.json")
+	if err := ioutil.WriteFile(other, []byte("{}"), 0644); err != nil {
+		t.Fatalf("unable to write %s: %v", other, err)
+	}
+	if err := persisted.Clear(); err != nil {
+		t.Fatalf("unable to clear the cache: %v", err)
+	}
+	if _, ok := New(dir, time.Hour).Get(key); ok {
+		t.Errorf("expected a cache miss after clearing the cache")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("expected the files other than the entries to be kept, got %v", err)
+	}
+	if err := New(filepath.Join(dir, "missing"), time.Hour).Clear(); err != nil {
+		t.Errorf("unexpected error clearing a missing cache directory: %v", err)
+	}
+}
+
+func TestCacheRolesProvider(t *testing.T) {
+	p := &countingProvider{versions: []string{"v1.0.0"}}
+	c := New("", time.Hour)
+
+	cached := c.RolesProvider("git", p)
+	for _, source := range []string{
+		"https://github.com/atosatto/ansible-minio",
+		"https://github.com/atosatto/ansible-minio.git",
+		"git+https://github.com/atosatto/ansible-minio/",
+	} {
+		versions, err := cached.VersionsForRole(context.Background(), types.Role{Source: source, Scm: "git"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(versions, p.versions) {
+			t.Errorf("expected %v, got %v", p.versions, versions)
+		}
+	}
+	if p.calls != 1 {
+		t.Errorf("expected equivalent sources to be fetched once, got %d lookups", p.calls)
+	}
+
+	// the same source served by a different
+	// provider is cached separately
+	if _, err := c.RolesProvider("galaxy", p).VersionsForRole(context.Background(), types.Role{Source: "https://github.com/atosatto/ansible-minio"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.calls != 2 {
+		t.Errorf("expected providers to be cached separately, got %d lookups", p.calls)
+	}
+}
+
+func TestCacheOffline(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	var fetchedAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var role = types.Role{Source: "https://github.com/atosatto/ansible-minio", Scm: "git"}
+
+	// seed the cache while online
+	p := &countingProvider{versions: []string{"v1.0.0"}}
+	online := New(dir, time.Hour)
+	online.now = func() time.Time { return fetchedAt }
+	if _, err := online.RolesProvider("git", p).VersionsForRole(context.Background(), role); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// export the cache to a snapshot
+	var snapshot bytes.Buffer
+	if err := online.WriteSnapshot(&snapshot); err != nil {
+		t.Fatalf("unable to write the snapshot: %v", err)
+	}
+	f, err := ioutil.TempFile("", "snapshot")
+	if err != nil {
+		t.Fatalf("unable to create the snapshot file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	f.Write(snapshot.Bytes())
+	f.Close()
+
+	for name, c := range map[string]*Cache{
+		"cache":    New(dir, time.Hour),
+		"snapshot": New("", time.Hour),
+	} {
+		c.WithOffline()
+		c.now = func() time.Time { return fetchedAt.Add(48 * time.Hour) }
+		if name == "snapshot" {
+			if err := c.LoadSnapshot(f.Name()); err != nil {
+				t.Fatalf("unable to load the snapshot: %v", err)
+			}
+		}
+
+		// stale entries are served without
+		// querying the upstream provider
+		l, err := provider.LookupRole(context.Background(), c.RolesProvider("git", p), role)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		expected := provider.Lookup{Versions: []string{"v1.0.0"}, FetchedAt: fetchedAt, Stale: true}
+		if !reflect.DeepEqual(l.Versions, expected.Versions) || !l.FetchedAt.Equal(expected.FetchedAt) || !l.Stale {
+			t.Errorf("%s: expected %+v, got %+v", name, expected, l)
+		}
+
+		// missing entries are reported as not cached
+		_, err = c.RolesProvider("git", p).VersionsForRole(context.Background(), types.Role{Source: "https://github.com/atosatto/ansible-grafana"})
+		if !errors.IsNotCachedError(err) {
+			t.Errorf("%s: expected a NotCachedError, got %v", name, err)
+		}
+	}
+	if p.calls != 1 {
+		t.Errorf("expected the upstream provider not to be queried offline, got %d lookups", p.calls)
+	}
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+// RolesProvider wraps the provider p, named name, so that the versions
+// of the roles are looked up in the Cache before querying p.
+func (c *Cache) RolesProvider(name string, p provider.RolesProvider) provider.RolesProvider {
+	return rolesProvider{cache: c, name: name, provider: p}
+}
+
+// CollectionsProvider wraps the provider p, named name, so that the versions
+// of the collections are looked up in the Cache before querying p.
+func (c *Cache) CollectionsProvider(name string, p provider.CollectionsProvider) provider.CollectionsProvider {
+	return collectionsProvider{cache: c, name: name, provider: p}
+}
+
+// lookup returns the versions cached for key, calling fetch
+// to get them from the upstream provider on cache misses.
+func (c *Cache) lookup(key Key, name string, fetch func() (provider.Lookup, error)) (provider.Lookup, error) {
+	if e, ok := c.entry(key); ok && (c.offline || !c.isStale(e)) {
+		return provider.Lookup{Versions: e.Versions, FetchedAt: e.FetchedAt, Stale: c.isStale(e), Server: e.Server}, nil
+	}
+	if c.offline {
+		return provider.Lookup{}, errors.NewNotCachedError(name)
+	}
+
+	l, err := fetch()
+	if err != nil {
+		return provider.Lookup{}, err
+	}
+	// failing to write the cache must not
+	// fail the lookup of the versions
+	c.store(Entry{Key: key, Versions: l.Versions, Server: l.Server, FetchedAt: c.now()})
+	return provider.Lookup{Versions: l.Versions, FetchedAt: c.now(), Server: l.Server}, nil
+}
+
+type rolesProvider struct {
+	cache    *Cache
+	name     string
+	provider provider.RolesProvider
+}
+
+// LookupRole returns the versions available for the Role r,
+// together with the time they have been fetched.
+func (p rolesProvider) LookupRole(ctx context.Context, r types.Role) (provider.Lookup, error) {
+	var source = r.Source
+	if len(source) == 0 {
+		source = r.Name
+	}
+	var key = Key{Provider: p.name, Kind: "role", Source: provider.NormalizeSource(source)}
+
+	return p.cache.lookup(key, source, func() (provider.Lookup, error) {
+		return provider.LookupRole(ctx, p.provider, r)
+	})
+}
+
+// VersionsForRole returns the list of versions available for the Role r.
+func (p rolesProvider) VersionsForRole(ctx context.Context, r types.Role) ([]string, error) {
+	l, err := p.LookupRole(ctx, r)
+	return l.Versions, err
+}
+
+type collectionsProvider struct {
+	cache    *Cache
+	name     string
+	provider provider.CollectionsProvider
+}
+
+// LookupCollection returns the versions available for the Collection c,
+// together with the time they have been fetched.
+func (p collectionsProvider) LookupCollection(ctx context.Context, c types.Collection) (provider.Lookup, error) {
+	var key = Key{Provider: p.name, Kind: "collection", Name: c.Name, Source: provider.NormalizeSource(c.Source)}
+
+	return p.cache.lookup(key, c.Name, func() (provider.Lookup, error) {
+		return provider.LookupCollection(ctx, p.provider, c)
+	})
+}
+
+// VersionsForCollection returns the list of versions available for the Collection c.
+func (p collectionsProvider) VersionsForCollection(ctx context.Context, c types.Collection) ([]string, error) {
+	l, err := p.LookupCollection(ctx, c)
+	return l.Versions, err
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+)
+
+// systemConfig is the path of the system wide
+// Ansible configuration file, it is defined as a
+// variable to allow mocking during unit tests.
+var systemConfig = "/etc/ansible/ansible.cfg"
+
+// Config holds the Ansible configuration relevant to the
+// linter, read from ansible.cfg and the ANSIBLE_* environment
+// variables as done by Ansible.
+type Config struct {
+	// Path is the path of the ansible.cfg file
+	// the configuration has been read from, if any.
+	Path string
+
+	// GalaxyServer is the URL of the Ansible Galaxy server
+	// used when no GalaxyServerList is configured.
+	GalaxyServer string
+
+	// GalaxyServerList is the ordered list of
+	// names of the Ansible Galaxy servers to use.
+	GalaxyServerList []string
+
+	// GalaxyServers holds the configuration of the
+	// Ansible Galaxy servers, keyed by name.
+	GalaxyServers map[string]provider.GalaxyServer
+
+	// GalaxyTokenPath is the path of the file storing
+	// the token of the GalaxyServer.
+	GalaxyTokenPath string
+
+	// RolesPath is the list of directories
+	// where roles are installed.
+	RolesPath []string
+}
+
+// Load finds and reads the Ansible configuration file,
+// applying the overrides of the ANSIBLE_* environment variables.
+// As Ansible does, the first existing file among ANSIBLE_CONFIG,
+// ansible.cfg in the current directory (unless world writable),
+// ~/.ansible.cfg and /etc/ansible/ansible.cfg is used.
+func Load() (*Config, error) {
+	c := &Config{
+		GalaxyServer:  provider.DefaultAnsibleGalaxyURL,
+		GalaxyServers: make(map[string]provider.GalaxyServer),
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		c.GalaxyTokenPath = filepath.Join(home, ".ansible", "galaxy_token")
+		c.RolesPath = []string{
+			filepath.Join(home, ".ansible", "roles"),
+			"/usr/share/ansible/roles",
+			"/etc/ansible/roles",
+		}
+	}
+
+	if path := Find(); len(path) != 0 {
+		if err := c.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+	c.applyEnv()
+	return c, nil
+}
+
+// Find returns the path of the Ansible configuration
+// file to be used, or a nil string if none exists.
+func Find() string {
+	var candidates []string
+	if path := os.Getenv("ANSIBLE_CONFIG"); len(path) != 0 {
+		path = expandUser(path)
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			path = filepath.Join(path, "ansible.cfg")
+		}
+		candidates = append(candidates, path)
+	}
+	if cwd, err := os.Getwd(); err == nil {
+		// Ansible ignores the configuration files
+		// found in world writable directories
+		if info, err := os.Stat(cwd); err == nil && info.Mode().Perm()&0002 == 0 {
+			candidates = append(candidates, filepath.Join(cwd, "ansible.cfg"))
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".ansible.cfg"))
+	}
+	candidates = append(candidates, systemConfig)
+
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// Servers returns the Ansible Galaxy servers to be queried, in order.
+// If no server list is configured, the GalaxyServer is returned,
+// authenticated with the token stored at GalaxyTokenPath, if any.
+func (c *Config) Servers() ([]provider.GalaxyServer, error) {
+	var servers []provider.GalaxyServer
+	for _, name := range c.GalaxyServerList {
+		s, ok := c.GalaxyServers[name]
+		if !ok || len(s.URL) == 0 {
+			continue
+		}
+		servers = append(servers, s)
+	}
+	if len(servers) != 0 {
+		return servers, nil
+	}
+
+	token, err := ReadGalaxyToken(c.GalaxyTokenPath)
+	if err != nil {
+		return nil, err
+	}
+	return []provider.GalaxyServer{{URL: c.GalaxyServer, Token: token}}, nil
+}
+
+// readFile reads the Ansible configuration file at path.
+func (c *Config) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sections, err := parseINI(f)
+	if err != nil {
+		return fmt.Errorf("unable to parse %s: %v", path, err)
+	}
+	c.Path = path
+
+	if v, ok := sections["defaults"]["roles_path"]; ok {
+		c.RolesPath = splitPaths(v)
+	}
+
+	var galaxy = sections["galaxy"]
+	if v, ok := galaxy["server"]; ok {
+		c.GalaxyServer = v
+	}
+	if v, ok := galaxy["server_list"]; ok {
+		c.GalaxyServerList = splitList(v)
+	}
+	if v, ok := galaxy["token_path"]; ok {
+		c.GalaxyTokenPath = expandUser(v)
+	}
+
+	for name, options := range sections {
+		if !strings.HasPrefix(name, "galaxy_server.") {
+			continue
+		}
+		name = strings.TrimPrefix(name, "galaxy_server.")
+		c.GalaxyServers[name] = provider.GalaxyServer{
+			Name:     name,
+			URL:      options["url"],
+			Token:    options["token"],
+			AuthURL:  options["auth_url"],
+			ClientID: options["client_id"],
+			Username: options["username"],
+			Password: options["password"],
+		}
+	}
+	return nil
+}
+
+// applyEnv applies the overrides of the ANSIBLE_* environment variables.
+func (c *Config) applyEnv() {
+	if v, ok := os.LookupEnv("ANSIBLE_ROLES_PATH"); ok {
+		c.RolesPath = splitPaths(v)
+	}
+	if v, ok := os.LookupEnv("ANSIBLE_GALAXY_SERVER"); ok {
+		c.GalaxyServer = v
+	}
+	if v, ok := os.LookupEnv("ANSIBLE_GALAXY_SERVER_LIST"); ok {
+		c.GalaxyServerList = splitList(v)
+	}
+	if v, ok := os.LookupEnv("ANSIBLE_GALAXY_TOKEN_PATH"); ok {
+		c.GalaxyTokenPath = expandUser(v)
+	}
+
+	for _, name := range c.GalaxyServerList {
+		s, ok := c.GalaxyServers[name]
+		if !ok {
+			s = provider.GalaxyServer{Name: name}
+		}
+		applyGalaxyServerEnv(&s)
+		c.GalaxyServers[name] = s
+	}
+}
+
+// applyGalaxyServerEnv overrides the configuration of the server
+// s with the ANSIBLE_GALAXY_SERVER_<NAME>_<KEY> environment variables.
+func applyGalaxyServerEnv(s *provider.GalaxyServer) {
+	for key, value := range map[string]*string{
+		"URL":       &s.URL,
+		"TOKEN":     &s.Token,
+		"AUTH_URL":  &s.AuthURL,
+		"CLIENT_ID": &s.ClientID,
+		"USERNAME":  &s.Username,
+		"PASSWORD":  &s.Password,
+	} {
+		if v, ok := os.LookupEnv("ANSIBLE_GALAXY_SERVER_" + strings.ToUpper(s.Name) + "_" + key); ok {
+			*value = v
+		}
+	}
+}
+
+// splitList splits a comma separated list of values.
+func splitList(v string) []string {
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); len(item) != 0 {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// splitPaths splits a colon separated list of paths.
+func splitPaths(v string) []string {
+	var paths []string
+	for _, p := range filepath.SplitList(v) {
+		if p = strings.TrimSpace(p); len(p) != 0 {
+			paths = append(paths, expandUser(p))
+		}
+	}
+	return paths
+}
+
+// expandUser replaces the leading ~ of
+// path with the user home directory.
+func expandUser(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "ansible-requirements-lint")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+	return dir
+}
+
+// setenv sets the given environment variables,
+// returning a function restoring their values.
+func setenv(t *testing.T, env map[string]string) func() {
+	var previous = make(map[string]*string)
+	for k, v := range env {
+		if old, ok := os.LookupEnv(k); ok {
+			previous[k] = &old
+		} else {
+			previous[k] = nil
+		}
+		if err := os.Setenv(k, v); err != nil {
+			t.Fatalf("unable to set %s: %v", k, err)
+		}
+	}
+	return func() {
+		for k, v := range previous {
+			if v == nil {
+				os.Unsetenv(k)
+			} else {
+				os.Setenv(k, *v)
+			}
+		}
+	}
+}
+
+// chdir changes the working directory to dir,
+// returning a function restoring the previous one.
+func chdir(t *testing.T, dir string) func() {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("unable to get the working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("unable to change the working directory: %v", err)
+	}
+	return func() { os.Chdir(cwd) }
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("unable to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("unable to write %s: %v", path, err)
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	var (
+		home    = filepath.Join(dir, "home")
+		project = filepath.Join(dir, "project")
+		custom  = filepath.Join(dir, "custom")
+	)
+	os.MkdirAll(home, 0755)
+	os.MkdirAll(project, 0755)
+
+	defer setenv(t, map[string]string{"HOME": home, "ANSIBLE_CONFIG": ""})()
+	defer chdir(t, project)()
+	defer func(path string) { systemConfig = path }(systemConfig)
+	systemConfig = filepath.Join(dir, "etc", "ansible.cfg")
+
+	if path := Find(); path != "" {
+		t.Errorf("expected no configuration file, got %s", path)
+	}
+
+	// each file takes precedence over the following ones
+	for _, c := range []struct {
+		path     string
+		expected string
+		env      string
+	}{
+		{path: systemConfig, expected: systemConfig},
+		{path: filepath.Join(home, ".ansible.cfg"), expected: filepath.Join(home, ".ansible.cfg")},
+		{path: filepath.Join(project, "ansible.cfg"), expected: filepath.Join(project, "ansible.cfg")},
+		{path: filepath.Join(custom, "ansible.cfg"), expected: filepath.Join(custom, "ansible.cfg"), env: custom},
+	} {
+		writeFile(t, c.path, "[defaults]\n")
+		os.Setenv("ANSIBLE_CONFIG", c.env)
+		if path := Find(); path != c.expected {
+			t.Errorf("expected %s, got %s", c.expected, path)
+		}
+	}
+
+	// files in world writable directories are ignored
+	os.Setenv("ANSIBLE_CONFIG", "")
+	os.Chmod(project, 0777)
+	if path := Find(); path != filepath.Join(home, ".ansible.cfg") {
+		t.Errorf("expected %s, got %s", filepath.Join(home, ".ansible.cfg"), path)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "ansible.cfg")
+	writeFile(t, path, `
+[defaults]
+roles_path = ~/roles:/etc/ansible/roles
+
+[galaxy]
+server_list = automation_hub, release_galaxy, missing
+
+[galaxy_server.automation_hub]
+url = https://console.redhat.com/api/automation-hub/
+auth_url = https://sso.redhat.com/auth/realms/redhat-external/protocol/openid-connect/token
+token = from-config
+
+[galaxy_server.release_galaxy]
+url = https://galaxy.ansible.com/
+`)
+
+	defer setenv(t, map[string]string{
+		"HOME":           dir,
+		"ANSIBLE_CONFIG": path,
+		"ANSIBLE_GALAXY_SERVER_AUTOMATION_HUB_TOKEN": "from-env",
+	})()
+
+	c, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Path != path {
+		t.Errorf("expected the configuration to be read from %s, got %s", path, c.Path)
+	}
+	if expected := []string{filepath.Join(dir, "roles"), "/etc/ansible/roles"}; !reflect.DeepEqual(c.RolesPath, expected) {
+		t.Errorf("expected roles path %v, got %v", expected, c.RolesPath)
+	}
+
+	servers, err := c.Servers()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []provider.GalaxyServer{
+		{
+			Name:    "automation_hub",
+			URL:     "https://console.redhat.com/api/automation-hub/",
+			AuthURL: "https://sso.redhat.com/auth/realms/redhat-external/protocol/openid-connect/token",
+			Token:   "from-env",
+		},
+		{
+			Name: "release_galaxy",
+			URL:  "https://galaxy.ansible.com/",
+		},
+	}
+	if !reflect.DeepEqual(servers, expected) {
+		t.Errorf("expected servers %+v, got %+v", expected, servers)
+	}
+
+	// without a server list, the default server
+	// is used with the token of ansible-galaxy login
+	writeFile(t, path, "[galaxy]\nserver = https://galaxy.example.com\n")
+	writeFile(t, filepath.Join(dir, ".ansible", "galaxy_token"), "token: secret\n")
+	if c, err = Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	servers, err = c.Servers()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []provider.GalaxyServer{{URL: "https://galaxy.example.com", Token: "secret"}}; !reflect.DeepEqual(servers, expected) {
+		t.Errorf("expected servers %+v, got %+v", expected, servers)
+	}
+}
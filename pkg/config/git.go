@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// DefaultGitCredentialsPath returns the default path of the file
+// holding the credentials used to access private Git repositories,
+// located in the user configuration directory
+// (e.g. $XDG_CONFIG_HOME on Linux).
+func DefaultGitCredentialsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ansible-requirements-lint", "git-credentials.yml")
+}
+
+// LoadGitCredentials reads the credentials used to access private
+// Git repositories from the YAML file at path, holding the list
+// of the credentials of each Git server. References to environment
+// variables (e.g. ${GITHUB_TOKEN}) in the values are expanded.
+// If the file does not exist, no credentials are returned, unless
+// the file is required, as when its path is set by the user.
+func LoadGitCredentials(path string, required bool) ([]provider.GitCredentials, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var credentials []provider.GitCredentials
+	if err := yaml.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", path, err)
+	}
+	for i := range credentials {
+		c := &credentials[i]
+		for _, v := range []*string{&c.Host, &c.Username, &c.Password, &c.Token, &c.IdentityFile, &c.IdentityPassphrase, &c.KnownHostsFile} {
+			*v = os.ExpandEnv(*v)
+		}
+		c.IdentityFile = expandUser(c.IdentityFile)
+		c.KnownHostsFile = expandUser(c.KnownHostsFile)
+	}
+	return credentials, nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+)
+
+func TestLoadGitCredentials(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	defer setenv(t, map[string]string{"HOME": dir, "GITHUB_TOKEN": "secret"})()
+
+	var path = filepath.Join(dir, "git-credentials.yml")
+	writeFile(t, path, `
+- host: github.com
+  token: ${GITHUB_TOKEN}
+- host: git.example.com
+  identity_file: ~/.ssh/deploy
+  known_hosts_file: ~/.ssh/known_hosts
+`)
+
+	credentials, err := LoadGitCredentials(path, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []provider.GitCredentials{
+		{Host: "github.com", Token: "secret"},
+		{Host: "git.example.com", IdentityFile: filepath.Join(dir, ".ssh", "deploy"), KnownHostsFile: filepath.Join(dir, ".ssh", "known_hosts")},
+	}
+	if !reflect.DeepEqual(credentials, expected) {
+		t.Errorf("expected %+v, got %+v", expected, credentials)
+	}
+
+	// missing credentials files are only an error when required
+	credentials, err = LoadGitCredentials(filepath.Join(dir, "missing"), false)
+	if err != nil || credentials != nil {
+		t.Errorf("expected no credentials, got %+v (%v)", credentials, err)
+	}
+	if _, err := LoadGitCredentials(filepath.Join(dir, "missing"), true); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+}
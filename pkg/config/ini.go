@@ -0,0 +1,77 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// section holds the options of a section
+// of an INI file, keyed by lower case name.
+type section map[string]string
+
+// parseINI parses the INI file read from r, with the same syntax
+// accepted by the Python configparser used by Ansible: options
+// are separated from their value by either = or :, lines starting
+// with # or ; are comments, as is everything following a ; preceded
+// by a whitespace, and indented lines continue the value of the
+// previous option. Sections and options names are case insensitive.
+func parseINI(r io.Reader) (map[string]section, error) {
+	var sections = make(map[string]section)
+	var current section
+	var lastKey string
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		var line = scanner.Text()
+		var trimmed = strings.TrimSpace(line)
+
+		switch {
+		case len(trimmed) == 0 || trimmed[0] == '#' || trimmed[0] == ';':
+			continue
+		case line[0] == ' ' || line[0] == '\t':
+			// continuation of the previous value
+			if current == nil || len(lastKey) == 0 {
+				return nil, fmt.Errorf("line %d: unexpected continuation line", n)
+			}
+			current[lastKey] = strings.TrimSpace(current[lastKey] + "\n" + stripInlineComment(trimmed))
+			continue
+		case trimmed[0] == '[':
+			if !strings.HasSuffix(trimmed, "]") {
+				return nil, fmt.Errorf("line %d: invalid section header %s", n, trimmed)
+			}
+			var name = strings.ToLower(strings.TrimSpace(trimmed[1 : len(trimmed)-1]))
+			if _, ok := sections[name]; !ok {
+				sections[name] = make(section)
+			}
+			current, lastKey = sections[name], ""
+			continue
+		}
+
+		if current == nil {
+			return nil, fmt.Errorf("line %d: option outside of any section", n)
+		}
+		var i = strings.IndexAny(trimmed, "=:")
+		if i < 1 {
+			return nil, fmt.Errorf("line %d: invalid option %s", n, trimmed)
+		}
+		lastKey = strings.ToLower(strings.TrimSpace(trimmed[:i]))
+		current[lastKey] = stripInlineComment(strings.TrimSpace(trimmed[i+1:]))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sections, nil
+}
+
+// stripInlineComment removes the inline comment,
+// starting with a ; preceded by a whitespace, from value.
+func stripInlineComment(value string) string {
+	for i := 1; i < len(value); i++ {
+		if value[i] == ';' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimSpace(value[:i])
+		}
+	}
+	return value
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseINI(t *testing.T) {
+	var data = `
+# comment
+[defaults]
+roles_path = ./roles:~/roles ; inline comment
+inventory=hosts
+
+; another comment
+[Galaxy]
+server_list: automation_hub,
+  release_galaxy
+
+[galaxy_server.automation_hub]
+URL = https://console.redhat.com/api/automation-hub/
+token = abc;def
+`
+	expected := map[string]section{
+		"defaults": {
+			"roles_path": "./roles:~/roles",
+			"inventory":  "hosts",
+		},
+		"galaxy": {
+			"server_list": "automation_hub,\nrelease_galaxy",
+		},
+		"galaxy_server.automation_hub": {
+			"url":   "https://console.redhat.com/api/automation-hub/",
+			"token": "abc;def",
+		},
+	}
+
+	sections, err := parseINI(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(sections, expected) {
+		t.Errorf("expected %+v, got %+v", expected, sections)
+	}
+
+	for _, invalid := range []string{
+		"key = value\n",
+		"[defaults\n",
+		"[defaults]\ninvalid\n",
+		"[defaults]\n  continuation\n",
+	} {
+		if _, err := parseINI(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expected an error parsing %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// DefaultUpdatePolicyPath is the default path of the file
+// holding the update policy, relative to the current directory.
+const DefaultUpdatePolicyPath = ".ansible-requirements-lint.yml"
+
+// UpdatePolicy is the YAML representation of the file holding the
+// update policy, whose versions increments and constraints are parsed
+// by the linter.
+type UpdatePolicy struct {
+	// Updates is the largest version increment
+	// reported as an update (patch, minor or major).
+	Updates string `yaml:"updates"`
+
+	// PreReleases allows updates to pre-release versions.
+	PreReleases bool `yaml:"pre_releases"`
+
+	// Constraints holds the version constraints
+	// of the roles and collections, keyed by name.
+	Constraints map[string]string `yaml:"constraints"`
+
+	// TagPrefixes holds the prefixes preceding the
+	// versions in the tags of the roles, keyed by name.
+	TagPrefixes map[string]string `yaml:"tag_prefixes"`
+}
+
+// LoadUpdatePolicy reads the UpdatePolicy from the YAML file at path,
+// holding the largest version increment reported as an update, whether
+// updates to pre-releases are allowed, the version constraints (e.g.
+// ~> 2.3 or >=1.2,<2.0) of the roles and collections, keyed by name,
+// and the prefixes of the tags of the roles (e.g. myrole- for the tags
+// myrole-1.2.3 of a repository hosting multiple roles). If the file does
+// not exist, an empty UpdatePolicy is returned, unless the file is required,
+// as when its path is set by the user.
+func LoadUpdatePolicy(path string, required bool) (UpdatePolicy, error) {
+	var policy UpdatePolicy
+	if len(path) == 0 {
+		return policy, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		return policy, nil
+	}
+	if err != nil {
+		return policy, err
+	}
+
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return policy, fmt.Errorf("unable to parse %s: %v", path, err)
+	}
+	return policy, nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadUpdatePolicy(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, ".ansible-requirements-lint.yml")
+	writeFile(t, path, `
+updates: minor
+pre_releases: true
+constraints:
+  atosatto.prometheus: "~> 2.3"
+  community.general: ">=1.2,<2.0"
+tag_prefixes:
+  atosatto.prometheus: prometheus-
+`)
+
+	policy, err := LoadUpdatePolicy(path, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if policy.Updates != "minor" || !policy.PreReleases {
+		t.Errorf("expected minor updates and pre-releases, got %+v", policy)
+	}
+	expected := map[string]string{"atosatto.prometheus": "~> 2.3", "community.general": ">=1.2,<2.0"}
+	if !reflect.DeepEqual(policy.Constraints, expected) {
+		t.Errorf("expected constraints %v, got %v", expected, policy.Constraints)
+	}
+
+	if expected := map[string]string{"atosatto.prometheus": "prometheus-"}; !reflect.DeepEqual(policy.TagPrefixes, expected) {
+		t.Errorf("expected tag prefixes %v, got %v", expected, policy.TagPrefixes)
+	}
+
+	// invalid files are an error
+	writeFile(t, path, "constraints: [atosatto.prometheus]\n")
+	if _, err := LoadUpdatePolicy(path, true); err == nil {
+		t.Errorf("expected an error parsing an invalid policy")
+	}
+
+	// missing policy files are only an error when required
+	if _, err := LoadUpdatePolicy(filepath.Join(dir, "missing"), true); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+	policy, err = LoadUpdatePolicy(filepath.Join(dir, "missing"), false)
+	if err != nil || !reflect.DeepEqual(policy, UpdatePolicy{}) {
+		t.Errorf("expected an empty policy, got %+v (%v)", policy, err)
+	}
+}
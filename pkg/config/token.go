@@ -0,0 +1,33 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// ReadGalaxyToken reads the Ansible Galaxy token stored in the file at path,
+// in the same format used by ansible-galaxy. If the file does not exist,
+// a nil string is returned.
+func ReadGalaxyToken(path string) (string, error) {
+	if len(path) == 0 {
+		return "", nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var file struct {
+		Token string `yaml:"token"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return "", err
+	}
+	return file.Token, nil
+}
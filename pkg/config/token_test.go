@@ -0,0 +1,29 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadGalaxyToken(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "galaxy_token")
+	if err := ioutil.WriteFile(path, []byte("token: secret\n"), 0600); err != nil {
+		t.Fatalf("unable to write the token file: %v", err)
+	}
+
+	token, err := ReadGalaxyToken(path)
+	if err != nil || token != "secret" {
+		t.Errorf("expected token secret, got %q (%v)", token, err)
+	}
+
+	// missing token files are not an error
+	token, err = ReadGalaxyToken(filepath.Join(dir, "missing"))
+	if err != nil || token != "" {
+		t.Errorf("expected no token, got %q (%v)", token, err)
+	}
+}
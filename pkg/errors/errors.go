@@ -2,6 +2,7 @@ package errors
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/atosatto/ansible-requirements-lint/pkg/types"
 )
@@ -81,3 +82,364 @@ func IsRoleVersionNotFoundError(err error) bool {
 	}
 	return false
 }
+
+// UnknownCollectionTypeError is returned when the
+// the value of the Type field of a Collection is not valid.
+type UnknownCollectionTypeError struct {
+	collectionType types.CollectionType
+}
+
+// NewUnknownCollectionTypeError creates a new UnknownCollectionTypeError
+func NewUnknownCollectionTypeError(collectionType types.CollectionType) *UnknownCollectionTypeError {
+	return &UnknownCollectionTypeError{collectionType: collectionType}
+}
+
+// Error converts an UnknownCollectionTypeError to string
+func (e *UnknownCollectionTypeError) Error() string {
+	return fmt.Sprintf("unknown or unsupported collection type %s", e.collectionType)
+}
+
+// IsUnknownCollectionTypeError checks whether err is an UnknownCollectionTypeError
+func IsUnknownCollectionTypeError(err error) bool {
+	if _, ok := err.(*UnknownCollectionTypeError); ok {
+		return true
+	}
+	return false
+}
+
+// CollectionNotFoundError is returned when it is not possible
+// to find the collection on the upstream source.
+type CollectionNotFoundError struct {
+	collection types.Collection
+	source     string
+}
+
+// NewCollectionNotFoundError creates a new CollectionNotFoundError
+func NewCollectionNotFoundError(collection types.Collection, source string) *CollectionNotFoundError {
+	return &CollectionNotFoundError{collection: collection, source: source}
+}
+
+// Error converts a CollectionNotFoundError to string
+func (e *CollectionNotFoundError) Error() string {
+	return fmt.Sprintf("unable to find collection %s on %s", e.collection.Name, e.source)
+}
+
+// IsCollectionNotFoundError checks whether err is a CollectionNotFoundError
+func IsCollectionNotFoundError(err error) bool {
+	if _, ok := err.(*CollectionNotFoundError); ok {
+		return true
+	}
+	return false
+}
+
+// CollectionVersionNotFoundError is returned when
+// the current collection version is not found in the list of the
+// available ones.
+type CollectionVersionNotFoundError struct {
+	collection types.Collection
+	available  []string
+}
+
+// NewCollectionVersionNotFoundError creates a new CollectionVersionNotFoundError
+func NewCollectionVersionNotFoundError(collection types.Collection, available []string) *CollectionVersionNotFoundError {
+	return &CollectionVersionNotFoundError{collection: collection, available: available}
+}
+
+// Error converts a CollectionVersionNotFoundError to string
+func (e *CollectionVersionNotFoundError) Error() string {
+	if len(e.available) == 0 {
+		return fmt.Sprintf("unable to find version %s for collection %s", e.collection.Version, e.collection.Name)
+	}
+	return fmt.Sprintf("unable to find version %s for collection %s in %v", e.collection.Version, e.collection.Name, e.available)
+}
+
+// IsCollectionVersionNotFoundError checks whether err is a CollectionVersionNotFoundError
+func IsCollectionVersionNotFoundError(err error) bool {
+	if _, ok := err.(*CollectionVersionNotFoundError); ok {
+		return true
+	}
+	return false
+}
+
+// NotCachedError is returned in offline mode when the
+// versions of a role or collection are not available
+// in the cache.
+type NotCachedError struct {
+	name string
+}
+
+// NewNotCachedError creates a new NotCachedError
+func NewNotCachedError(name string) *NotCachedError {
+	return &NotCachedError{name: name}
+}
+
+// Error converts a NotCachedError to string
+func (e *NotCachedError) Error() string {
+	return fmt.Sprintf("no cached versions available for %s in offline mode", e.name)
+}
+
+// IsNotCachedError checks whether err is a NotCachedError
+func IsNotCachedError(err error) bool {
+	if _, ok := err.(*NotCachedError); ok {
+		return true
+	}
+	return false
+}
+
+// InvalidRoleNameError is returned when the name of
+// a role to be looked up on Ansible Galaxy is not in
+// the namespace.name format.
+type InvalidRoleNameError struct {
+	name string
+}
+
+// NewInvalidRoleNameError creates a new InvalidRoleNameError
+func NewInvalidRoleNameError(name string) *InvalidRoleNameError {
+	return &InvalidRoleNameError{name: name}
+}
+
+// Error converts an InvalidRoleNameError to string
+func (e *InvalidRoleNameError) Error() string {
+	return fmt.Sprintf("invalid Ansible Galaxy role name %s, roles must be referenced as namespace.name", e.name)
+}
+
+// IsInvalidRoleNameError checks whether err is an InvalidRoleNameError
+func IsInvalidRoleNameError(err error) bool {
+	if _, ok := err.(*InvalidRoleNameError); ok {
+		return true
+	}
+	return false
+}
+
+// DependenciesNotResolvedError is returned when the
+// dependencies of a role cannot be resolved, because
+// the role cannot be fetched or its metadata parsed.
+type DependenciesNotResolvedError struct {
+	name string
+	err  error
+}
+
+// NewDependenciesNotResolvedError creates a new DependenciesNotResolvedError
+func NewDependenciesNotResolvedError(name string, err error) *DependenciesNotResolvedError {
+	return &DependenciesNotResolvedError{name: name, err: err}
+}
+
+// Error converts a DependenciesNotResolvedError to string
+func (e *DependenciesNotResolvedError) Error() string {
+	return fmt.Sprintf("unable to resolve the dependencies of %s: %v", e.name, e.err)
+}
+
+// IsDependenciesNotResolvedError checks whether err is a DependenciesNotResolvedError
+func IsDependenciesNotResolvedError(err error) bool {
+	if _, ok := err.(*DependenciesNotResolvedError); ok {
+		return true
+	}
+	return false
+}
+
+// DependencyConflictError is returned when a role is
+// required at different versions by the requirements
+// file and by the roles depending on it.
+type DependencyConflictError struct {
+	name         string
+	requirements []types.Role
+}
+
+// NewDependencyConflictError creates a new DependencyConflictError for the
+// role with the given name, required at different versions by requirements.
+func NewDependencyConflictError(name string, requirements []types.Role) *DependencyConflictError {
+	return &DependencyConflictError{name: name, requirements: requirements}
+}
+
+// Error converts a DependencyConflictError to string
+func (e *DependencyConflictError) Error() string {
+	var required = make([]string, len(e.requirements))
+	for i, r := range e.requirements {
+		if len(r.Via) == 0 {
+			required[i] = fmt.Sprintf("%s in the requirements file", r.Version)
+			continue
+		}
+		var chain = make([]string, len(r.Via))
+		for j, v := range r.Via {
+			chain[j] = roleName(v)
+			if len(v.Version) != 0 {
+				chain[j] += "@" + v.Version
+			}
+		}
+		required[i] = fmt.Sprintf("%s via %s", r.Version, strings.Join(chain, " > "))
+	}
+	return fmt.Sprintf("role %s is required at conflicting versions: %s", e.name, strings.Join(required, ", "))
+}
+
+// IsDependencyConflictError checks whether err is a DependencyConflictError
+func IsDependencyConflictError(err error) bool {
+	if _, ok := err.(*DependencyConflictError); ok {
+		return true
+	}
+	return false
+}
+
+// NotLockedError is returned when a role or collection
+// is not in the lock file, or is locked at a version
+// not matching the requirements file.
+type NotLockedError struct {
+	kind    string
+	name    string
+	version string
+}
+
+// NewNotLockedError creates a new NotLockedError for the role or collection,
+// depending on kind, with the given name. The version is the one the role or
+// collection is locked at, and must be empty when it is not in the lock file.
+func NewNotLockedError(kind, name, version string) *NotLockedError {
+	return &NotLockedError{kind: kind, name: name, version: version}
+}
+
+// Error converts a NotLockedError to string
+func (e *NotLockedError) Error() string {
+	if len(e.version) == 0 {
+		return fmt.Sprintf("%s %s is not in the lock file, lock the requirements again", e.kind, e.name)
+	}
+	return fmt.Sprintf("%s %s is locked at version %s, which does not match the requirements file, lock the requirements again", e.kind, e.name, e.version)
+}
+
+// IsNotLockedError checks whether err is a NotLockedError
+func IsNotLockedError(err error) bool {
+	if _, ok := err.(*NotLockedError); ok {
+		return true
+	}
+	return false
+}
+
+// LockMismatchError is returned when the locked version of a
+// role or collection has been changed upstream, as when a tag
+// has been moved to a different commit or an archive replaced.
+type LockMismatchError struct {
+	kind     string
+	name     string
+	version  string
+	field    string
+	locked   string
+	obtained string
+}
+
+// NewLockMismatchError creates a new LockMismatchError for the version of the
+// role or collection, depending on kind, with the given name, whose commit or
+// checksum, depending on field, is obtained instead of the locked one.
+func NewLockMismatchError(kind, name, version, field, locked, obtained string) *LockMismatchError {
+	return &LockMismatchError{kind: kind, name: name, version: version, field: field, locked: locked, obtained: obtained}
+}
+
+// Error converts a LockMismatchError to string
+func (e *LockMismatchError) Error() string {
+	return fmt.Sprintf("version %s of %s %s has changed upstream: the %s is %s, but %s is locked", e.version, e.kind, e.name, e.field, e.obtained, e.locked)
+}
+
+// IsLockMismatchError checks whether err is a LockMismatchError
+func IsLockMismatchError(err error) bool {
+	if _, ok := err.(*LockMismatchError); ok {
+		return true
+	}
+	return false
+}
+
+// TagMovedError is returned when the tag a role is pinned to
+// no longer points to the commit recorded for it, as when
+// a release has been re-tagged or force-pushed.
+type TagMovedError struct {
+	name     string
+	tag      string
+	recorded string
+	commit   string
+}
+
+// NewTagMovedError creates a new TagMovedError for the tag of the role
+// with the given name, which points to commit instead of the recorded one.
+func NewTagMovedError(name, tag, recorded, commit string) *TagMovedError {
+	return &TagMovedError{name: name, tag: tag, recorded: recorded, commit: commit}
+}
+
+// Error converts a TagMovedError to string
+func (e *TagMovedError) Error() string {
+	return fmt.Sprintf("tag %s of role %s has been moved from commit %s to %s", e.tag, e.name, e.recorded, e.commit)
+}
+
+// IsTagMovedError checks whether err is a TagMovedError
+func IsTagMovedError(err error) bool {
+	if _, ok := err.(*TagMovedError); ok {
+		return true
+	}
+	return false
+}
+
+// UnpinnedVersionError is returned when a role is pinned to
+// a kind of reference not allowed by the pinning policy, as
+// a branch, or when it is not pinned to any version.
+type UnpinnedVersionError struct {
+	name    string
+	version string
+	kind    string
+	allowed []string
+}
+
+// NewUnpinnedVersionError creates a new UnpinnedVersionError for the role with
+// the given name, whose version is a reference of the given kind (e.g. branch),
+// while the pinning policy only allows the kinds of references in allowed.
+func NewUnpinnedVersionError(name, version, kind string, allowed []string) *UnpinnedVersionError {
+	return &UnpinnedVersionError{name: name, version: version, kind: kind, allowed: allowed}
+}
+
+// Error converts an UnpinnedVersionError to string
+func (e *UnpinnedVersionError) Error() string {
+	if len(e.version) == 0 {
+		return fmt.Sprintf("role %s is not pinned to any version, while the pinning policy requires a %s", e.name, strings.Join(e.allowed, " or "))
+	}
+	return fmt.Sprintf("role %s is pinned to the %s %s, while the pinning policy requires a %s", e.name, e.kind, e.version, strings.Join(e.allowed, " or "))
+}
+
+// IsUnpinnedVersionError checks whether err is an UnpinnedVersionError
+func IsUnpinnedVersionError(err error) bool {
+	if _, ok := err.(*UnpinnedVersionError); ok {
+		return true
+	}
+	return false
+}
+
+// NoReleasesError is returned when none of the
+// tags of a role or collection is a version.
+type NoReleasesError struct {
+	kind string
+	name string
+	tags int
+}
+
+// NewNoReleasesError creates a new NoReleasesError for the role or collection,
+// depending on kind, with the given name, having tags tags which are not versions.
+func NewNoReleasesError(kind, name string, tags int) *NoReleasesError {
+	return &NoReleasesError{kind: kind, name: name, tags: tags}
+}
+
+// Error converts a NoReleasesError to string
+func (e *NoReleasesError) Error() string {
+	if e.tags == 0 {
+		return fmt.Sprintf("no releases found for %s %s, unable to check for updates", e.kind, e.name)
+	}
+	return fmt.Sprintf("no releases found for %s %s, none of its tags is a version", e.kind, e.name)
+}
+
+// IsNoReleasesError checks whether err is a NoReleasesError
+func IsNoReleasesError(err error) bool {
+	if _, ok := err.(*NoReleasesError); ok {
+		return true
+	}
+	return false
+}
+
+// roleName returns the Name of the Role or,
+// if not set, its Source.
+func roleName(role types.Role) string {
+	if len(role.Name) == 0 {
+		return role.Source
+	}
+	return role.Name
+}
@@ -0,0 +1,156 @@
+package fixer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines
+// printed around the changes in a unified diff.
+const diffContext = 3
+
+// UnifiedDiff returns the differences between the
+// content a and b of the file at path, in unified format.
+// An empty string is returned if a and b are equal.
+func UnifiedDiff(path string, a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+
+	var linesA, linesB = splitLines(a), splitLines(b)
+	var ops = diffLines(linesA, linesB)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", path, path)
+
+	// group the operations in hunks, merging
+	// changes closer than twice the context
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			// look for the next change within the context
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' && next-end < 2*diffContext {
+				next++
+			}
+			if next < len(ops) && ops[next].kind != ' ' {
+				end = next
+				continue
+			}
+			break
+		}
+		stop := end + diffContext
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		var countA, countB int
+		for _, op := range ops[start:stop] {
+			if op.kind != '+' {
+				countA++
+			}
+			if op.kind != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", hunkStart(ops[start].lineA, countA), countA, hunkStart(ops[start].lineB, countB), countB)
+		for _, op := range ops[start:stop] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			if !strings.HasSuffix(op.text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = stop
+	}
+
+	return out.String()
+}
+
+// diffOp is a line of a diff. kind is either ' ' for unchanged
+// lines, '-' for removed lines or '+' for added lines. lineA and lineB
+// are the 0-based indexes of the line in the original and new content.
+type diffOp struct {
+	kind         byte
+	text         string
+	lineA, lineB int
+}
+
+// diffLines computes the differences between two list of lines
+// relying on the longest common subsequence of the two lists.
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] holds the length of the longest common
+	// subsequence between a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	var i, j = 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{kind: ' ', text: a[i], lineA: i, lineB: j})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, diffOp{kind: '+', text: b[j], lineA: i, lineB: j})
+			j++
+		default:
+			ops = append(ops, diffOp{kind: '-', text: a[i], lineA: i, lineB: j})
+			i++
+		}
+	}
+	return ops
+}
+
+// hunkStart returns the 1-based line number of the start of a
+// hunk, which is conventionally the line preceding the hunk when
+// the hunk is empty.
+func hunkStart(line, count int) int {
+	if count == 0 {
+		return line
+	}
+	return line + 1
+}
+
+// splitLines splits data in lines, keeping the line terminators.
+func splitLines(data []byte) []string {
+	var lines []string
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			lines = append(lines, string(data))
+			break
+		}
+		lines = append(lines, string(data[:i+1]))
+		data = data[i+1:]
+	}
+	return lines
+}
@@ -0,0 +1,160 @@
+package fixer
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/linter"
+	"github.com/atosatto/ansible-requirements-lint/pkg/parser"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fix is an update of the version
+// of a role or collection to be applied
+// to a requirements file.
+type Fix struct {
+	// Result is the Linter result
+	// the Fix has been created from.
+	Result linter.Result
+
+	// Span is the location of the version
+	// value in the requirements file.
+	Span types.Span
+
+	// Version is the new version
+	// of the role or collection.
+	Version string
+}
+
+// FixesFromResults returns the Fixes for the updates reported
+// in the given Linter results, grouped by requirements file.
+// Only updates not exceeding maxBump will be returned.
+func FixesFromResults(results []linter.Result, maxBump linter.Bump) map[string][]Fix {
+	var fixes = make(map[string][]Fix)
+	for _, res := range results {
+		update, ok := res.Metadata.(linter.Update)
+		if !ok || !update.IsUpdate {
+			continue
+		}
+
+		// version constraints of collections can't be
+		// replaced by a single version without changing
+		// the meaning of the requirement
+		if len(res.Collection.Name) != 0 && strings.ContainsAny(res.Collection.Version, "<>=!~,*") {
+			continue
+		}
+
+		if bump := update.Bump(); bump == linter.BumpNone || bump > maxBump {
+			continue
+		}
+
+		var pos = res.Position()
+		if len(pos.File) == 0 || pos.Version.IsZero() {
+			continue
+		}
+
+		fixes[pos.File] = append(fixes[pos.File], Fix{
+			Result:  res,
+			Span:    pos.Version,
+			Version: update.ToVersion,
+		})
+	}
+	return fixes
+}
+
+// Apply applies the given Fixes to the content of a requirements file.
+// The version values to be updated are looked up and edited in the
+// yaml.Node tree of the file, and the changes are then spliced back in
+// the original content, so that comments, ordering, quoting
+// and indentation are preserved. Fixes of the same version value,
+// as for files included by more than one requirements file, are
+// applied once.
+func Apply(data []byte, fixes []Fix) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	// index the scalar nodes by location
+	var scalars = make(map[[2]int]*yaml.Node)
+	var index func(n *yaml.Node)
+	index = func(n *yaml.Node) {
+		if n.Kind == yaml.ScalarNode {
+			scalars[[2]int{n.Line, n.Column}] = n
+		}
+		for _, c := range n.Content {
+			index(c)
+		}
+	}
+	index(&root)
+
+	type edit struct {
+		start, end int
+		value      string
+	}
+	var edits []edit
+	var seen = make(map[types.Span]bool)
+	for _, f := range fixes {
+		if seen[f.Span] {
+			continue
+		}
+		seen[f.Span] = true
+
+		n, ok := scalars[[2]int{f.Span.Line, f.Span.Column}]
+		if !ok {
+			return nil, fmt.Errorf("unable to find the version value at line %d, column %d", f.Span.Line, f.Span.Column)
+		}
+		n.Value = f.Version
+
+		start := parser.Offset(data, f.Span.Line, f.Span.Column)
+		end := parser.Offset(data, f.Span.EndLine, f.Span.EndColumn)
+		if start < 0 || end <= start {
+			return nil, fmt.Errorf("invalid location of the version value at line %d, column %d", f.Span.Line, f.Span.Column)
+		}
+
+		// the commit recorded by the comment following
+		// the version does not apply to the new version
+		if len(parser.RecordedCommit(n.LineComment)) != 0 {
+			var line = data[end:]
+			if i := bytes.IndexByte(line, '\n'); i >= 0 {
+				line = line[:i]
+			}
+			if i := bytes.Index(line, []byte(n.LineComment)); i >= 0 && len(bytes.TrimSpace(line[:i])) == 0 {
+				end += i + len(n.LineComment)
+			}
+		}
+		edits = append(edits, edit{start: start, end: end, value: render(n)})
+	}
+
+	// splice the edited values in the original content
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var res bytes.Buffer
+	var last = 0
+	for _, e := range edits {
+		if e.start < last {
+			return nil, fmt.Errorf("overlapping fixes at offset %d", e.start)
+		}
+		res.Write(data[last:e.start])
+		res.WriteString(e.value)
+		last = e.end
+	}
+	res.Write(data[last:])
+	return res.Bytes(), nil
+}
+
+// render returns the text representation of the
+// scalar node n, preserving its quoting style.
+func render(n *yaml.Node) string {
+	switch {
+	case n.Style&yaml.DoubleQuotedStyle != 0:
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(n.Value) + `"`
+	case n.Style&yaml.SingleQuotedStyle != 0:
+		return `'` + strings.Replace(n.Value, `'`, `''`, -1) + `'`
+	default:
+		return n.Value
+	}
+}
@@ -0,0 +1,141 @@
+package fixer
+
+import (
+	"testing"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/linter"
+	"github.com/atosatto/ansible-requirements-lint/pkg/parser"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+var requirements = `---
+# Prometheus
+- name: test.ansible-requirements-lint-plain
+  version: v1.0.0 # pinned
+
+# Alertmanager
+- name: test.ansible-requirements-lint-double
+  version:   "v1.0.0"  # commit: 5f1e2d3
+
+# Grafana
+- name: test.ansible-requirements-lint-single
+  version: 'v1.0.0'
+`
+
+// resultsFor returns Linter results updating each role
+// in the requirements to the corresponding version.
+func resultsFor(t *testing.T, versions ...string) []linter.Result {
+	parsed, err := parser.Unmarshal([]byte(requirements))
+	if err != nil {
+		t.Fatalf("expected no error, obtained %+v", err)
+	}
+
+	var results []linter.Result
+	for i, r := range parsed.Roles {
+		r.Position.File = "requirements.yml"
+		results = append(results, linter.Result{
+			Role:     r,
+			Level:    linter.LevelWarning,
+			Metadata: linter.Update{FromVersion: r.Version, ToVersion: versions[i], IsUpdate: r.Version != versions[i]},
+		})
+	}
+	return results
+}
+
+// TestApply tests that fixes preserve the
+// formatting of the requirements file, except for
+// the comments recording the commit of the version.
+func TestApply(t *testing.T) {
+	fixes := FixesFromResults(resultsFor(t, "v1.0.1", "v1.1.0", "v2.0.0"), linter.BumpMajor)
+	fixed, err := Apply([]byte(requirements), fixes["requirements.yml"])
+	if err != nil {
+		t.Fatalf("expected no error, obtained %+v", err)
+	}
+
+	expected := `---
+# Prometheus
+- name: test.ansible-requirements-lint-plain
+  version: v1.0.1 # pinned
+
+# Alertmanager
+- name: test.ansible-requirements-lint-double
+  version:   "v1.1.0"
+
+# Grafana
+- name: test.ansible-requirements-lint-single
+  version: 'v2.0.0'
+`
+	if string(fixed) != expected {
+		t.Errorf("expecting fixed requirements\n%s\nobtained\n%s", expected, fixed)
+	}
+
+	// files included twice are fixed once
+	fixed, err = Apply([]byte(requirements), append(fixes["requirements.yml"], fixes["requirements.yml"]...))
+	if err != nil || string(fixed) != expected {
+		t.Errorf("expecting duplicated fixes to be applied once, obtained\n%s\n(%v)", fixed, err)
+	}
+}
+
+// TestFixesFromResultsMaxBump tests that fixes exceeding
+// the maximum version increment are skipped.
+func TestFixesFromResultsMaxBump(t *testing.T) {
+	cases := map[linter.Bump]int{
+		linter.BumpPatch: 1,
+		linter.BumpMinor: 2,
+		linter.BumpMajor: 3,
+	}
+
+	for bump, expected := range cases {
+		fixes := FixesFromResults(resultsFor(t, "v1.0.1", "v1.1.0", "v2.0.0"), bump)
+		if len(fixes["requirements.yml"]) != expected {
+			t.Errorf("%d: expecting %d fixes, obtained %d", bump, expected, len(fixes["requirements.yml"]))
+		}
+	}
+}
+
+// TestFixesFromResultsCollections tests that version
+// constraints of collections are not fixed.
+func TestFixesFromResultsCollections(t *testing.T) {
+	var span = types.Span{Line: 1, Column: 1, EndLine: 1, EndColumn: 2}
+	results := []linter.Result{
+		{
+			Collection: types.Collection{Name: "test.pinned", Version: "1.0.0", Position: types.Position{File: "requirements.yml", Version: span}},
+			Metadata:   linter.Update{FromVersion: "1.0.0", ToVersion: "1.1.0", IsUpdate: true},
+		},
+		{
+			Collection: types.Collection{Name: "test.range", Version: ">=1.0.0,<1.1.0", Position: types.Position{File: "requirements.yml", Version: span}},
+			Metadata:   linter.Update{FromVersion: ">=1.0.0,<1.1.0", ToVersion: "1.1.0", IsUpdate: true},
+		},
+	}
+
+	fixes := FixesFromResults(results, linter.BumpMajor)
+	if len(fixes["requirements.yml"]) != 1 || fixes["requirements.yml"][0].Result.Collection.Name != "test.pinned" {
+		t.Errorf("expecting a single fix for test.pinned, obtained %+v", fixes)
+	}
+}
+
+// TestUnifiedDiff tests the unified diff of
+// the changes applied to a requirements file.
+func TestUnifiedDiff(t *testing.T) {
+	fixes := FixesFromResults(resultsFor(t, "v1.0.1", "v1.0.0", "v1.0.0"), linter.BumpMajor)
+	fixed, err := Apply([]byte(requirements), fixes["requirements.yml"])
+	if err != nil {
+		t.Fatalf("expected no error, obtained %+v", err)
+	}
+
+	expected := `--- a/requirements.yml
++++ b/requirements.yml
+@@ -1,7 +1,7 @@
+ ---
+ # Prometheus
+ - name: test.ansible-requirements-lint-plain
+-  version: v1.0.0 # pinned
++  version: v1.0.1 # pinned
+ 
+ # Alertmanager
+ - name: test.ansible-requirements-lint-double
+`
+	if diff := UnifiedDiff("requirements.yml", []byte(requirements), fixed); diff != expected {
+		t.Errorf("expecting diff\n%s\nobtained\n%s", expected, diff)
+	}
+}
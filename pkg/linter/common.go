@@ -1,32 +1,118 @@
 package linter
 
 import (
-	"fmt"
-	"hash/fnv"
-	"sort"
+	"context"
+	"strings"
+	"sync"
 
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
 	"github.com/atosatto/ansible-requirements-lint/pkg/types"
 	version "github.com/hashicorp/go-version"
 )
 
-// roleHash returns an hash of the given Role to be used
-// as key for the UpdatesLinter cache.
-func roleHash(r types.Role) string {
-	h := fnv.New32a()
-	h.Write([]byte(r.Source))
-	return fmt.Sprintf("%x", h.Sum32())
+// latestMatchingVersion returns the latest version in the provided
+// list of version tags satisfying the given constraints, or any
+// version if constraints is nil. As done by ansible-galaxy,
+// pre-releases are only returned when explicitly required
+// by the constraints, and tags which are not semantic versions
+// are ignored. An empty string is returned if no version matches.
+func latestMatchingVersion(tags []string, constraints version.Constraints) string {
+	var versions, _ = parseVersions(tags, "")
+
+	var latest *Version
+	for _, v := range versions {
+		switch {
+		case constraints == nil && len(v.Prerelease()) != 0:
+			continue
+		case constraints != nil && !v.Check(constraints):
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+		}
+	}
+	return versionTag(latest)
 }
 
-// latestVersion returns the latest semanting version
-// in the provided list of version tags.
-func latestVersion(tags []string) string {
-	versions := make([]*version.Version, len(tags))
-	for i, t := range tags {
-		v, _ := version.NewVersion(t)
-		versions[i] = v
+// pinLatestRole returns the given Role pinned to the latest of its versions
+// allowed by the UpdatePolicy, as ansible-galaxy installs the latest version
+// of the Ansible Galaxy roles without a version. Roles with a version, or
+// hosted on Git repositories, are returned unchanged, as well as the roles
+// without versions, which are installed from their default branch.
+func pinLatestRole(ctx context.Context, p provider.RolesProvider, scm string, role types.Role, policy UpdatePolicy) (types.Role, error) {
+	if scm != ansibleGalaxy || len(role.Version) != 0 {
+		return role, nil
 	}
 
-	sort.Sort(version.Collection(versions))
-	last := versions[len(versions)-1]
-	return last.Original()
+	versions, err := p.VersionsForRole(ctx, role)
+	if err != nil {
+		return role, err
+	}
+	role.Version = versionTag(policy.releases(versions, "", role.Name, role.Source).allowed)
+	return role, nil
+}
+
+// containsVersion checks whether v is part of the
+// provided list of versions.
+func containsVersion(versions []string, v string) bool {
+	for _, version := range versions {
+		if version == v {
+			return true
+		}
+	}
+	return false
+}
+
+// versionConstraints parses the version of a Collection
+// as a list of version constraints (e.g. >=1.0.0,<2.0.0).
+// As done by ansible-galaxy, a missing version is parsed as *.
+// The returned bool is false when v is not a range of versions
+// but refers to a single version, as for Roles.
+func versionConstraints(v string) (version.Constraints, bool) {
+	v = strings.TrimSpace(v)
+	if len(v) == 0 {
+		v = "*"
+	}
+	if v != "*" && !strings.ContainsAny(v, "<>=!~,") {
+		return nil, false
+	}
+
+	constraints, err := ParseConstraints(v)
+	if err != nil {
+		return nil, false
+	}
+	return constraints, true
+}
+
+// forEach calls fn with the indexes from 0 to n-1 in a pool of
+// parallelism workers, returning once all the calls are done.
+// No more calls are made once the context is done.
+func forEach(ctx context.Context, parallelism, n int, fn func(i int)) {
+	var queue = make(chan int)
+	go func() {
+		defer close(queue)
+		for i := 0; i < n; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case queue <- i:
+			}
+		}
+	}()
+
+	var workers = parallelism
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
 }
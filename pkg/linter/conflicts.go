@@ -0,0 +1,146 @@
+package linter
+
+import (
+	"context"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+// Conflict holds the requirements of a role
+// required at conflicting versions.
+type Conflict struct {
+	// Requirements are the declarations of the role, either in
+	// the requirements file or as a dependency of other roles,
+	// in the order they are found in the dependency graph.
+	Requirements []types.Role
+}
+
+// Versions returns the distinct versions the role is required at.
+// Tags of the same version (e.g. v1.0.0 and 1.0.0) are returned once,
+// as first found among the requirements.
+func (c Conflict) Versions() []string {
+	var versions []string
+	for _, r := range c.Requirements {
+		var found bool
+		for _, v := range versions {
+			found = found || sameVersion(v, r.Version)
+		}
+		if !found {
+			versions = append(versions, r.Version)
+		}
+	}
+	return versions
+}
+
+// ConflictsLinter checks for roles required at different versions
+// across the dependency graph of the requirements, either by two
+// roles depending on them, or by the requirements file and a role.
+// As ansible-galaxy only installs one version of each role, the roles
+// depending on the other versions may not work as expected.
+type ConflictsLinter struct {
+	dependencies *DependencyResolver
+
+	// parallelism is the number of roles whose
+	// dependencies are resolved concurrently
+	parallelism int
+}
+
+// NewConflictsLinter returns a new ConflictsLinter building
+// the dependency graph with the given DependencyResolver.
+func NewConflictsLinter(d *DependencyResolver) *ConflictsLinter {
+	return &ConflictsLinter{
+		dependencies: d,
+		parallelism:  DefaultParallelism,
+	}
+}
+
+// WithParallelism configures the number of roles whose
+// dependencies are resolved concurrently by the ConflictsLinter.
+func (c *ConflictsLinter) WithParallelism(n int) {
+	c.parallelism = n
+}
+
+// Lint checks for conflicting version requirements in the dependency graph of
+// the Roles defined in the given Requirements, and in all the Requirements files
+// they include. A Result is sent on the output channel for each role required at
+// conflicting versions, with the Metadata field set to a Conflict holding all its
+// requirements. Roles required without a version accept any version, so they are
+// never in conflict. Errors resolving the dependencies are not reported, as they
+// are reported by the UpdatesLinter when configured with the same DependencyResolver.
+func (c *ConflictsLinter) Lint(ctx context.Context, requirements *types.Requirements, output chan<- Result) error {
+	// make sure to close the results chan on exit
+	defer close(output)
+
+	var roles []types.Role
+	requirements.Walk(func(r *types.Requirements) error {
+		for _, role := range r.Roles {
+			if len(role.Include) == 0 {
+				roles = append(roles, role)
+			}
+		}
+		return nil
+	})
+
+	// resolve the dependencies of the roles
+	// in a pool of workers, collecting the
+	// requirements in the requirements files order
+	var graph = make([][]types.Role, len(roles))
+	forEach(ctx, c.parallelism, len(roles), func(i int) {
+		c.dependencies.Walk(ctx, roles[i], func(r types.Role, err error) {
+			graph[i] = append(graph[i], r)
+		})
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	// group the requirements of each role
+	var order []string
+	var byRole = make(map[string][]types.Role)
+	for _, requirements := range graph {
+		for _, r := range requirements {
+			if len(r.Version) == 0 {
+				continue
+			}
+			var id = roleIdentity(r)
+			if _, ok := byRole[id]; !ok {
+				order = append(order, id)
+			}
+			byRole[id] = append(byRole[id], r)
+		}
+	}
+
+	for _, id := range order {
+		var conflict = Conflict{Requirements: byRole[id]}
+		if len(conflict.Versions()) < 2 {
+			continue
+		}
+
+		// the conflict is reported at the declaration of the
+		// role in the requirements file, if any, or at the
+		// first requirement, without the location of its
+		// version, as there is no version to update
+		var role = conflict.Requirements[0]
+		for _, r := range conflict.Requirements {
+			if len(r.Via) == 0 {
+				role = r
+				break
+			}
+		}
+		role.Position.Version = types.Span{}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case output <- Result{
+			Role:     role,
+			Level:    LevelWarning,
+			Err:      errors.NewDependencyConflictError(roleName(role), conflict.Requirements),
+			Metadata: conflict,
+			Linter:   "conflicts",
+		}:
+		}
+	}
+	return nil
+}
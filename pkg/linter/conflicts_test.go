@@ -0,0 +1,72 @@
+package linter
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+func TestConflictsLinter(t *testing.T) {
+	d, dir := newMockDependencyResolver(t)
+	defer os.RemoveAll(dir)
+
+	var pos = types.Position{File: "requirements.yml", Line: 5, Column: 3, Version: types.Span{Line: 6, Column: 14, EndLine: 6, EndColumn: 20}}
+	requirements := &types.Requirements{
+		Roles: []types.Role{
+			{Name: "test.a", Version: "v1.0.0"},
+			// test.d depends on test.b at the same version as test.a
+			{Name: "test.d", Version: "v1.0.0"},
+		},
+		Childrens: []*types.Requirements{{
+			Roles: []types.Role{
+				{Name: "test.b", Version: "v1.1.0", Position: pos},
+				// roles without a version are not in conflict
+				{Name: "test.c"},
+			},
+		}},
+	}
+
+	results := make(chan Result)
+	go NewConflictsLinter(d).Lint(context.Background(), requirements, results)
+
+	var obtained []Result
+	for res := range results {
+		obtained = append(obtained, res)
+	}
+	if len(obtained) != 1 {
+		t.Fatalf("expecting one conflict, obtained %+v", obtained)
+	}
+
+	var res = obtained[0]
+	if res.Role.Name != "test.b" || len(res.Role.Via) != 0 || res.Level != LevelWarning || !errors.IsDependencyConflictError(res.Err) {
+		t.Errorf("expecting a conflict warning on the test.b declaration, obtained %+v", res)
+	}
+	if res.Position().Line != pos.Line || !res.Position().Version.IsZero() {
+		t.Errorf("expecting the conflict at line %d without the version location, obtained %+v", pos.Line, res.Position())
+	}
+	if versions := res.Metadata.(Conflict).Versions(); !reflect.DeepEqual(versions, []string{"v1.0.0", "v1.1.0"}) {
+		t.Errorf("expecting conflicting versions v1.0.0 and v1.1.0, obtained %v", versions)
+	}
+
+	expected := "role test.b is required at conflicting versions: v1.0.0 via test.a@v1.0.0, v1.0.0 via test.d@v1.0.0, v1.1.0 in the requirements file"
+	if res.Err.Error() != expected {
+		t.Errorf("expecting error %q, obtained %q", expected, res.Err.Error())
+	}
+}
+
+func TestConflictVersions(t *testing.T) {
+	var conflict Conflict
+	for _, v := range []string{"v1.0.0", "1.0.0", "v1.1.0", "myrole-1.0.0", "2020-01-15", "2020.01.15", "master"} {
+		conflict.Requirements = append(conflict.Requirements, types.Role{Name: "test.role", Version: v})
+	}
+
+	// tags of the same version are not in conflict
+	expected := []string{"v1.0.0", "v1.1.0", "myrole-1.0.0", "2020-01-15", "master"}
+	if versions := conflict.Versions(); !reflect.DeepEqual(versions, expected) {
+		t.Errorf("expecting versions %v, obtained %v", expected, versions)
+	}
+}
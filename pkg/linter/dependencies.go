@@ -0,0 +1,357 @@
+package linter
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/parser"
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+	"gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/osfs"
+	"gopkg.in/yaml.v3"
+)
+
+// MaxDependencyDepth is the maximum length of the chain
+// of transitive dependencies resolved for a role.
+const MaxDependencyDepth = 16
+
+// metaFiles are the files of a role, relative to its
+// root directory, declaring the dependencies of the role.
+var metaFiles = []string{
+	"meta/main.yml",
+	"meta/main.yaml",
+	"meta/requirements.yml",
+	"meta/requirements.yaml",
+}
+
+// DependencyResolver resolves the transitive dependencies of roles. As
+// ansible-galaxy install does, each role is fetched at the version it is
+// pinned to, and its dependencies are read from its meta/main.yml and
+// meta/requirements.yml files. Roles already installed in the roles paths
+// at the same version are read from there instead of being fetched.
+type DependencyResolver struct {
+	// rolesProviders are defined as attribute of the
+	// DependencyResolver struct to allow mocking during unit tests
+	rolesProviders map[string]provider.RolesProvider
+
+	// rolesPaths are the directories
+	// the roles are installed into
+	rolesPaths []string
+
+	// offline, if true, prevents the
+	// roles from being fetched
+	offline bool
+
+	// limiter limits the number of concurrent
+	// fetches sent to each provider
+	limiter *Limiter
+
+	// policy selects the version of the
+	// roles without a version
+	policy UpdatePolicy
+
+	// dependencies holds the direct dependencies
+	// of each role, indexed by the roleKey
+	mu           sync.Mutex
+	dependencies map[string]*roleDependencies
+}
+
+// roleDependencies holds the direct dependencies of a role,
+// which are resolved only once per DependencyResolver.
+type roleDependencies struct {
+	mu       sync.Mutex
+	resolved bool
+	roles    []types.Role
+	err      error
+}
+
+// NewDependencyResolver returns a new DependencyResolver
+// fetching the roles from Git and the default Ansible Galaxy.
+func NewDependencyResolver() *DependencyResolver {
+	return &DependencyResolver{
+		rolesProviders: map[string]provider.RolesProvider{
+			git:           provider.NewGit(),
+			ansibleGalaxy: provider.NewGalaxyServers(),
+		},
+		dependencies: make(map[string]*roleDependencies),
+		limiter:      NewLimiter(DefaultGalaxyConcurrency, DefaultGitConcurrency),
+		policy:       DefaultUpdatePolicy,
+	}
+}
+
+// WithGalaxyServers configures the DependencyResolver to
+// fetch the roles from the given Ansible Galaxy servers,
+// queried in order until one hosting the role is found.
+func (d *DependencyResolver) WithGalaxyServers(servers ...provider.GalaxyServer) {
+	d.rolesProviders[ansibleGalaxy] = provider.NewGalaxyServers(servers...)
+}
+
+// WithGitCredentials configures the DependencyResolver to use the given
+// credentials to access the Git repositories of each Git server.
+func (d *DependencyResolver) WithGitCredentials(credentials ...provider.GitCredentials) {
+	d.rolesProviders[git] = provider.NewGit(credentials...)
+}
+
+// WithRolesPaths configures the DependencyResolver to read the roles
+// installed by ansible-galaxy in the given directories, when they are
+// installed at the version the roles are pinned to.
+func (d *DependencyResolver) WithRolesPaths(paths ...string) {
+	d.rolesPaths = paths
+}
+
+// WithLimiter configures the Limiter limiting the number of
+// concurrent fetches sent by the DependencyResolver to each
+// provider, instead of the default limits.
+func (d *DependencyResolver) WithLimiter(l *Limiter) {
+	d.limiter = l
+}
+
+// WithUpdatePolicy configures the UpdatePolicy selecting the version
+// the Ansible Galaxy roles without a version are fetched at, instead
+// of the DefaultUpdatePolicy.
+func (d *DependencyResolver) WithUpdatePolicy(policy UpdatePolicy) {
+	d.policy = policy
+}
+
+// WithOffline configures the DependencyResolver to only read
+// the roles installed in the roles paths, without fetching them.
+func (d *DependencyResolver) WithOffline() {
+	d.offline = true
+}
+
+// Walk calls fn for role and, in depth-first order, for each of its transitive
+// dependencies, together with the error preventing the resolution of their
+// dependencies, if any. Dependencies have the chain of roles through which
+// they have been pulled in as Via, and the Position of role, without the
+// location of the version, as they are not declared in the requirements file.
+// Dependencies are not resolved for the roles already in the chain, to break
+// dependency cycles, and for roles distributed as archives.
+func (d *DependencyResolver) Walk(ctx context.Context, role types.Role, fn func(types.Role, error)) {
+	d.walk(ctx, role, role.Position, fn)
+}
+
+func (d *DependencyResolver) walk(ctx context.Context, role types.Role, pos types.Position, fn func(types.Role, error)) {
+	if _, _, err := roleScm(role); err != nil {
+		// the role can't be fetched
+		fn(role, nil)
+		return
+	}
+	if len(role.Via) >= MaxDependencyDepth {
+		fn(role, errors.NewDependenciesNotResolvedError(roleName(role), fmt.Errorf("more than %d levels of dependencies", MaxDependencyDepth)))
+		return
+	}
+
+	deps, err := d.resolve(ctx, role)
+	if err != nil {
+		err = errors.NewDependenciesNotResolvedError(roleName(role), err)
+	}
+	fn(role, err)
+
+	var parent = role
+	parent.Via = nil
+	var via = append(append([]types.Role{}, role.Via...), parent)
+
+	for _, dep := range deps {
+		if ctx.Err() != nil {
+			return
+		}
+		if inChain(dep, via) {
+			// dependency cycle
+			continue
+		}
+		dep.Via = via
+		dep.Position = pos
+		dep.Position.Version = types.Span{}
+		d.walk(ctx, dep, pos, fn)
+	}
+}
+
+// resolve returns the direct dependencies of role, which are
+// resolved at most once for each role. Resolutions interrupted
+// by the cancellation of ctx are not stored, so that they are
+// retried by the callers with a different context.
+func (d *DependencyResolver) resolve(ctx context.Context, role types.Role) ([]types.Role, error) {
+	var key = roleKey(role)
+
+	d.mu.Lock()
+	if d.dependencies == nil {
+		d.dependencies = make(map[string]*roleDependencies)
+	}
+	deps, ok := d.dependencies[key]
+	if !ok {
+		deps = &roleDependencies{}
+		d.dependencies[key] = deps
+	}
+	d.mu.Unlock()
+
+	deps.mu.Lock()
+	defer deps.mu.Unlock()
+	if deps.resolved {
+		return deps.roles, deps.err
+	}
+
+	roles, err := d.dependenciesOf(ctx, role)
+	if err != nil && ctx.Err() != nil {
+		return nil, err
+	}
+	deps.roles, deps.err, deps.resolved = roles, err, true
+	return roles, err
+}
+
+// dependenciesOf reads the direct dependencies of role from its
+// meta files, either from the roles paths or fetching the role.
+func (d *DependencyResolver) dependenciesOf(ctx context.Context, role types.Role) ([]types.Role, error) {
+	fs, err := d.fetch(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	var deps []types.Role
+	for _, name := range metaFiles {
+		f, err := fs.Open(name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		requirements, err := parser.Unmarshal(content)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %s: %v", name, err)
+		}
+		for _, r := range requirements.Roles {
+			if len(r.Include) == 0 {
+				deps = append(deps, r)
+			}
+		}
+	}
+	return deps, nil
+}
+
+// fetch returns the files of role, as installed in the roles
+// paths or, if not installed, as fetched by its provider.
+func (d *DependencyResolver) fetch(ctx context.Context, role types.Role) (billy.Filesystem, error) {
+	if dir, ok := d.installed(role); ok {
+		return osfs.New(dir), nil
+	}
+	if d.offline {
+		return nil, fmt.Errorf("the role is not installed in the roles path and can't be fetched in offline mode")
+	}
+
+	scm, _, err := roleScm(role)
+	if err != nil {
+		return nil, err
+	}
+	fetcher, ok := d.rolesProviders[scm].(provider.RolesFetcher)
+	if !ok {
+		return nil, fmt.Errorf("fetching roles is not supported by the %s provider", scm)
+	}
+
+	release, err := d.limiter.acquire(ctx, scm)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	role, err = pinLatestRole(ctx, d.rolesProviders[scm], scm, role, d.policy)
+	if err != nil {
+		return nil, err
+	}
+	return fetcher.FetchRole(ctx, role)
+}
+
+// installed returns the directory role is installed into,
+// if it is installed at its version in one of the roles paths.
+// The version of the installed roles is read from the metadata
+// written by ansible-galaxy at install time.
+func (d *DependencyResolver) installed(role types.Role) (string, bool) {
+	for _, p := range d.rolesPaths {
+		var dir = filepath.Join(p, installName(role))
+		content, err := ioutil.ReadFile(filepath.Join(dir, "meta", ".galaxy_install_info"))
+		if err != nil {
+			continue
+		}
+
+		var info struct {
+			Version string `yaml:"version"`
+		}
+		if err := yaml.Unmarshal(content, &info); err != nil {
+			continue
+		}
+		if len(role.Version) == 0 || info.Version == role.Version {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+// installName returns the name of the directory
+// ansible-galaxy installs the given Role into.
+func installName(role types.Role) string {
+	if len(role.Name) != 0 {
+		return role.Name
+	}
+
+	// the name of the role is derived from the name of
+	// the repository or archive, as done by ansible-galaxy
+	var name = path.Base(strings.TrimSuffix(provider.GitURL(role.Source), "/"))
+	name = strings.SplitN(name, ",", 2)[0]
+	for _, ext := range []string{".git", ".tar.gz", ".tar", ".zip"} {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return name
+}
+
+// roleKey returns a key identifying the given Role
+// and its version, so that equivalent declarations
+// of the same role have the same key.
+func roleKey(role types.Role) string {
+	var source = role.Source
+	if len(source) == 0 {
+		source = role.Name
+	}
+	scm, _, _ := roleScm(role)
+	return strings.Join([]string{scm, provider.NormalizeSource(source), role.Version}, "\x00")
+}
+
+// inChain checks whether the given Role,
+// at any version, is part of the chain.
+func inChain(role types.Role, chain []types.Role) bool {
+	var id = roleIdentity(role)
+	for _, r := range chain {
+		if roleIdentity(r) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// roleIdentity returns a key identifying the
+// given Role regardless of its version.
+func roleIdentity(role types.Role) string {
+	role.Version = ""
+	return roleKey(role)
+}
+
+// roleName returns the Name of the Role or,
+// if not set, its Source.
+func roleName(role types.Role) string {
+	if len(role.Name) == 0 {
+		return role.Source
+	}
+	return role.Name
+}
@@ -0,0 +1,202 @@
+package linter
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+	"gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+)
+
+// mockFetcherProvider serves the roles in its
+// map, indexed by name@version, holding the
+// content of the meta/main.yml of each role.
+type mockFetcherProvider map[string]string
+
+func (g mockFetcherProvider) VersionsForRole(ctx context.Context, r types.Role) ([]string, error) {
+	return []string{"v1.0.0", "v1.1.0"}, nil
+}
+
+func (g mockFetcherProvider) FetchRole(ctx context.Context, r types.Role) (billy.Filesystem, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	meta, ok := g[roleName(r)+"@"+r.Version]
+	if !ok {
+		return nil, errors.NewRoleNotFoundError(r, "mockFetcherProvider")
+	}
+	fs := memfs.New()
+	f, _ := fs.Create("meta/main.yml")
+	f.Write([]byte(meta))
+	f.Close()
+	return fs, nil
+}
+
+func newMockDependencyResolver(t *testing.T) (*DependencyResolver, string) {
+	dir, err := ioutil.TempDir("", "ansible-requirements-lint")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+
+	// test.d is only installed in the roles path
+	var meta = filepath.Join(dir, "test.d", "meta")
+	os.MkdirAll(meta, 0755)
+	ioutil.WriteFile(filepath.Join(meta, ".galaxy_install_info"), []byte("install_date: today\nversion: v1.0.0\n"), 0644)
+	ioutil.WriteFile(filepath.Join(meta, "main.yml"), []byte("dependencies:\n  - role: test.b\n    version: v1.0.0\n"), 0644)
+
+	d := NewDependencyResolver()
+	d.rolesProviders[ansibleGalaxy] = mockFetcherProvider{
+		"test.a@v1.0.0": `
+galaxy_info:
+  author: test
+dependencies:
+  - role: test.b
+    version: v1.0.0
+  - src: test.c
+    version: v1.0.0
+    some_var: value
+`,
+		// dependency cycle
+		"test.b@v1.0.0": "dependencies:\n  - {role: test.a, version: v1.0.0}\n",
+		"test.c@v1.0.0": "dependencies:\n  - test.broken\n",
+		"test.e@v1.1.0": "dependencies:\n  - {role: test.b, version: v1.0.0}\n",
+	}
+	d.WithRolesPaths(filepath.Join(dir, "missing"), dir)
+	return d, dir
+}
+
+func TestDependencyResolver(t *testing.T) {
+	d, dir := newMockDependencyResolver(t)
+	defer os.RemoveAll(dir)
+
+	var pos = types.Position{File: "requirements.yml", Line: 2, Column: 3, Version: types.Span{Line: 3, Column: 12, EndLine: 3, EndColumn: 18}}
+	var visited []string
+	var via = make(map[string][]string)
+	d.Walk(context.Background(), types.Role{Name: "test.a", Version: "v1.0.0", Position: pos}, func(r types.Role, err error) {
+		var name = roleName(r)
+		if err != nil {
+			if !errors.IsDependenciesNotResolvedError(err) {
+				t.Errorf("%s: expecting a DependenciesNotResolvedError, obtained %v", name, err)
+			}
+			name += " (error)"
+		}
+		visited = append(visited, name)
+		for _, v := range r.Via {
+			via[name] = append(via[name], roleName(v)+"@"+v.Version)
+		}
+		if len(r.Via) != 0 && (r.Position.File != pos.File || r.Position.Line != pos.Line || !r.Position.Version.IsZero()) {
+			t.Errorf("%s: expecting the position of the requiring role, obtained %+v", name, r.Position)
+		}
+	})
+
+	expected := []string{"test.a", "test.b", "test.c", "test.broken (error)"}
+	if !reflect.DeepEqual(expected, visited) {
+		t.Errorf("expecting roles %v, obtained %v", expected, visited)
+	}
+	expectedVia := map[string][]string{
+		"test.b":              {"test.a@v1.0.0"},
+		"test.c":              {"test.a@v1.0.0"},
+		"test.broken (error)": {"test.a@v1.0.0", "test.c@v1.0.0"},
+	}
+	if !reflect.DeepEqual(expectedVia, via) {
+		t.Errorf("expecting chains %v, obtained %v", expectedVia, via)
+	}
+
+	// roles without a version are fetched at their latest version
+	visited = nil
+	d.Walk(context.Background(), types.Role{Name: "test.e"}, func(r types.Role, err error) {
+		visited = append(visited, fmt.Sprintf("%s %v", roleName(r), err != nil))
+	})
+	expected = []string{"test.e false", "test.b false", "test.a false", "test.c false", "test.broken true"}
+	if !reflect.DeepEqual(expected, visited) {
+		t.Errorf("expecting roles %v, obtained %v", expected, visited)
+	}
+
+	// roles installed in the roles path are read from there, and
+	// the dependencies already resolved are not fetched again,
+	// so they are available even in offline mode
+	d.WithOffline()
+	visited = nil
+	d.Walk(context.Background(), types.Role{Name: "test.d", Version: "v1.0.0"}, func(r types.Role, err error) {
+		visited = append(visited, fmt.Sprintf("%s %v", roleName(r), err != nil))
+	})
+	expected = []string{"test.d false", "test.b false", "test.a false", "test.c false", "test.broken true"}
+	if !reflect.DeepEqual(expected, visited) {
+		t.Errorf("expecting roles %v, obtained %v", expected, visited)
+	}
+}
+
+func TestDependencyResolverCancel(t *testing.T) {
+	d, dir := newMockDependencyResolver(t)
+	defer os.RemoveAll(dir)
+
+	var role = types.Role{Name: "test.a", Version: "v1.0.0"}
+	var walk = func(ctx context.Context) error {
+		var walkErr error
+		d.Walk(ctx, role, func(r types.Role, err error) {
+			if roleName(r) == "test.a" {
+				walkErr = err
+			}
+		})
+		return walkErr
+	}
+
+	// the resolutions interrupted by the cancellation
+	// of the context are retried by the next callers
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := walk(ctx); err == nil {
+		t.Errorf("expecting an error with a cancelled context")
+	}
+	if err := walk(context.Background()); err != nil {
+		t.Errorf("expecting the dependencies to be resolved, obtained %v", err)
+	}
+}
+
+func TestUpdatesLinterDependencies(t *testing.T) {
+	d, dir := newMockDependencyResolver(t)
+	defer os.RemoveAll(dir)
+
+	updatesLinter := &UpdatesLinter{
+		rolesProviders: map[string]provider.RolesProvider{
+			ansibleGalaxy: d.rolesProviders[ansibleGalaxy],
+		},
+		dependencies: d,
+	}
+
+	requirements := &types.Requirements{Roles: []types.Role{
+		{Name: "test.a", Version: "v1.0.0"},
+		{Name: "test.d", Version: "v1.1.0"},
+	}}
+	results := make(chan Result)
+	go updatesLinter.Lint(context.Background(), requirements, results)
+
+	var obtained []string
+	for res := range results {
+		obtained = append(obtained, fmt.Sprintf("%s %d %s", roleName(res.Role), len(res.Role.Via), res.Level))
+	}
+
+	// test.b is reported once, test.broken is not pinned to
+	// any version, and test.d can not be resolved as it is
+	// installed at a different version
+	expected := []string{
+		"test.a 0 WARN",
+		"test.b 1 WARN",
+		"test.c 1 WARN",
+		"test.broken 2 INFO",
+		"test.broken 2 WARN",
+		"test.d 0 INFO",
+		"test.d 0 WARN",
+	}
+	if !reflect.DeepEqual(expected, obtained) {
+		t.Errorf("expecting results %v, obtained %v", expected, obtained)
+	}
+}
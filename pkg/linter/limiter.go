@@ -0,0 +1,47 @@
+package linter
+
+import (
+	"context"
+)
+
+// Limiter limits the number of concurrent lookups sent to each
+// provider. A Limiter can be shared by the Linters, the Locker
+// and the DependencyResolver, so that the limits hold for all
+// the lookups sent by them.
+type Limiter struct {
+	semaphores map[string]chan struct{}
+}
+
+// NewLimiter returns a new Limiter allowing at most galaxyConcurrency
+// concurrent lookups on Ansible Galaxy, and gitConcurrency on Git
+// repositories. The lookups sent to a provider are not limited if
+// its limit is lower than 1.
+func NewLimiter(galaxyConcurrency, gitConcurrency int) *Limiter {
+	l := &Limiter{semaphores: make(map[string]chan struct{})}
+	for p, n := range map[string]int{ansibleGalaxy: galaxyConcurrency, git: gitConcurrency} {
+		if n > 0 {
+			l.semaphores[p] = make(chan struct{}, n)
+		}
+	}
+	return l
+}
+
+// acquire waits for the provider p to be available
+// for a new lookup, returning a function to be called
+// to release the provider once the lookup is completed.
+// A nil Limiter does not limit the lookups.
+func (l *Limiter) acquire(ctx context.Context, p string) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	sem, ok := l.semaphores[p]
+	if !ok {
+		return func() {}, nil
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	}
+}
@@ -0,0 +1,71 @@
+package linter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	limiter := NewLimiter(0, 2)
+
+	var mu sync.Mutex
+	var running, maxRunning = map[string]int{}, map[string]int{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		for _, p := range []string{git, ansibleGalaxy} {
+			wg.Add(1)
+			go func(p string) {
+				defer wg.Done()
+				release, err := limiter.acquire(context.Background(), p)
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+					return
+				}
+				defer release()
+
+				mu.Lock()
+				running[p]++
+				if running[p] > maxRunning[p] {
+					maxRunning[p] = running[p]
+				}
+				mu.Unlock()
+
+				time.Sleep(5 * time.Millisecond)
+
+				mu.Lock()
+				running[p]--
+				mu.Unlock()
+			}(p)
+		}
+	}
+	wg.Wait()
+
+	if maxRunning[git] > 2 {
+		t.Errorf("expecting at most 2 concurrent Git lookups, obtained %d", maxRunning[git])
+	}
+	if maxRunning[ansibleGalaxy] < 3 {
+		t.Errorf("expecting Ansible Galaxy lookups not to be limited, obtained %d concurrent lookups", maxRunning[ansibleGalaxy])
+	}
+
+	// waiting for a provider is interrupted by the context
+	ctx, cancel := context.WithCancel(context.Background())
+	release1, _ := limiter.acquire(ctx, git)
+	release2, _ := limiter.acquire(ctx, git)
+	cancel()
+	if _, err := limiter.acquire(ctx, git); err == nil {
+		t.Errorf("expecting an error acquiring a provider with a cancelled context")
+	}
+	release1()
+	release2()
+
+	// a nil Limiter does not limit the lookups
+	var unlimited *Limiter
+	if release, err := unlimited.acquire(context.Background(), git); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else {
+		release()
+	}
+}
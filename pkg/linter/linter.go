@@ -31,6 +31,11 @@ type Result struct {
 	// The role evaluated by the Linter.
 	Role types.Role
 
+	// The collection evaluated by the Linter.
+	// It is set, in place of the Role, only for
+	// results referring to Ansible Collections.
+	Collection types.Collection
+
 	// The level of severity of the
 	// Linter result.
 	Level Level
@@ -45,6 +50,19 @@ type Result struct {
 	// Implementation specific
 	// Linter Metadata.
 	Metadata interface{}
+
+	// The name of the Linter which
+	// produced the Result (e.g. updates).
+	Linter string
+}
+
+// Position returns the location of the definition
+// of the role or collection the Result refers to.
+func (r Result) Position() types.Position {
+	if len(r.Collection.Name) != 0 {
+		return r.Collection.Position
+	}
+	return r.Role.Position
 }
 
 // A Linter analyzes Ansible requirements definitions
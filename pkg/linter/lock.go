@@ -0,0 +1,443 @@
+package linter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/lockfile"
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+	version "github.com/hashicorp/go-version"
+)
+
+// commitProvider is implemented by the RolesProviders resolving
+// the versions of the roles to commits, such as provider.Git.
+type commitProvider interface {
+	CommitForVersion(ctx context.Context, r types.Role, version string) (string, error)
+}
+
+// Locker resolves the roles and collections of the requirements files to
+// exact versions, together with the commit they resolve to, for the ones
+// hosted on Git repositories, or the checksum of their archive, for the
+// ones distributed by Ansible Galaxy.
+type Locker struct {
+	// rolesProviders and collectionsProviders are defined
+	// as attribute of the Locker struct to allow mocking
+	// during unit tests
+	rolesProviders       map[string]provider.RolesProvider
+	collectionsProviders map[string]provider.CollectionsProvider
+
+	// parallelism is the number of roles
+	// and collections locked concurrently
+	parallelism int
+
+	// limiter limits the number of concurrent
+	// lookups sent to each provider
+	limiter *Limiter
+
+	// policy selects the version of the
+	// roles without a version
+	policy UpdatePolicy
+}
+
+// NewLocker returns a new Locker resolving the
+// roles and collections on Git and the default
+// Ansible Galaxy.
+func NewLocker() *Locker {
+	l := &Locker{
+		rolesProviders: map[string]provider.RolesProvider{
+			git: provider.NewGit(),
+		},
+		collectionsProviders: make(map[string]provider.CollectionsProvider),
+		parallelism:          DefaultParallelism,
+		limiter:              NewLimiter(DefaultGalaxyConcurrency, DefaultGitConcurrency),
+		policy:               DefaultUpdatePolicy,
+	}
+	l.WithGalaxyServers()
+	return l
+}
+
+// WithGalaxyServers configures the Locker to resolve the roles
+// and collections on the given Ansible Galaxy servers, queried
+// in order until one hosting the role or collection is found.
+func (l *Locker) WithGalaxyServers(servers ...provider.GalaxyServer) {
+	galaxy := provider.NewGalaxyServers(servers...)
+	l.rolesProviders[ansibleGalaxy] = galaxy
+	l.collectionsProviders[ansibleGalaxy] = galaxy
+}
+
+// WithGitCredentials configures the Locker to use the given
+// credentials to access the Git repositories of each Git server.
+func (l *Locker) WithGitCredentials(credentials ...provider.GitCredentials) {
+	l.rolesProviders[git] = provider.NewGit(credentials...)
+}
+
+// WithParallelism configures the number of roles and
+// collections locked concurrently by the Locker.
+func (l *Locker) WithParallelism(n int) {
+	l.parallelism = n
+}
+
+// WithLimiter configures the Limiter limiting the number
+// of concurrent lookups sent by the Locker to each provider,
+// instead of the default limits.
+func (l *Locker) WithLimiter(limiter *Limiter) {
+	l.limiter = limiter
+}
+
+// WithUpdatePolicy configures the UpdatePolicy selecting the version
+// the Ansible Galaxy roles without a version are locked to, instead
+// of the DefaultUpdatePolicy.
+func (l *Locker) WithUpdatePolicy(policy UpdatePolicy) {
+	l.policy = policy
+}
+
+// Lock resolves the Roles and Collections defined in the given Requirements,
+// and in all the Requirements files they include, returning the Lockfile
+// recording them in the same order they are declared in the Requirements.
+// The roles and collections which cannot be locked are left out of the
+// Lockfile, and reported by the returned Results: with LevelInfo when
+// they cannot be resolved to a version, as for archives served by custom
+// webservers, and with LevelError when their resolution failed.
+func (l *Locker) Lock(ctx context.Context, requirements *types.Requirements) (*lockfile.Lockfile, []Result) {
+	var roles, collections = requirementsOf(requirements)
+
+	var lockedRoles = make([]lockfile.Role, len(roles))
+	var lockedCollections = make([]lockfile.Collection, len(collections))
+	var results = make([]*Result, len(roles)+len(collections))
+	forEach(ctx, l.parallelism, len(roles)+len(collections), func(i int) {
+		if i < len(roles) {
+			locked, level, err := l.lockRole(ctx, roles[i])
+			if err != nil {
+				results[i] = &Result{Role: roles[i], Level: level, Err: err}
+				return
+			}
+			lockedRoles[i] = locked
+			return
+		}
+
+		var j = i - len(roles)
+		locked, level, err := l.lockCollection(ctx, collections[j])
+		if err != nil {
+			results[i] = &Result{Collection: collections[j], Level: level, Err: err}
+			return
+		}
+		lockedCollections[j] = locked
+	})
+
+	var lock = &lockfile.Lockfile{}
+	var lockResults []Result
+	for i, res := range results {
+		switch {
+		case res != nil:
+			lockResults = append(lockResults, *res)
+		case ctx.Err() != nil:
+			// the role or collection has not been locked
+		case i < len(roles):
+			lock.Roles = append(lock.Roles, lockedRoles[i])
+		default:
+			lock.Collections = append(lock.Collections, lockedCollections[i-len(roles)])
+		}
+	}
+	return lock, lockResults
+}
+
+// lockRole resolves the given Role to the commit its version points
+// to, or to the checksum of the archive of its version. If the Role
+// cannot be locked, the error is returned together with its level
+// of severity.
+func (l *Locker) lockRole(ctx context.Context, role types.Role) (lockfile.Role, Level, error) {
+	scm, level, err := roleScm(role)
+	if err != nil {
+		if level == LevelInfo {
+			// we can't lock tarballs uploaded on a custom webserver
+			err = fmt.Errorf("unable to lock roles distributed via custom webservers")
+		}
+		return lockfile.Role{}, level, err
+	}
+
+	release, err := l.limiter.acquire(ctx, scm)
+	if err != nil {
+		return lockfile.Role{}, LevelError, err
+	}
+	defer release()
+
+	role, err = pinLatestRole(ctx, l.rolesProviders[scm], scm, role, l.policy)
+	if err != nil {
+		return lockfile.Role{}, LevelError, err
+	}
+
+	var locked = lockfile.Role{
+		Name:    role.Name,
+		Source:  role.Source,
+		Scm:     role.Scm,
+		Version: role.Version,
+	}
+	switch p := l.rolesProviders[scm].(type) {
+	case commitProvider:
+		commit, err := p.CommitForVersion(ctx, role, role.Version)
+		if err != nil {
+			return lockfile.Role{}, LevelError, err
+		}
+		locked.Commit = commit
+	case provider.RoleArtifactsProvider:
+		artifact, err := p.RoleArtifact(ctx, role)
+		if err != nil {
+			return lockfile.Role{}, LevelError, err
+		}
+		locked.Version = artifact.Version
+		locked.Checksum = lockfile.Checksum(artifact.Checksum)
+	default:
+		return lockfile.Role{}, LevelError, fmt.Errorf("locking roles is not supported by the %s provider", scm)
+	}
+	return locked, "", nil
+}
+
+// lockCollection resolves the given Collection to the latest version
+// satisfying its version constraints, and to the checksum of the archive
+// of that version or, for collections hosted on Git repositories, to the
+// commit its version points to. If the Collection cannot be locked, the
+// error is returned together with its level of severity.
+func (l *Locker) lockCollection(ctx context.Context, collection types.Collection) (lockfile.Collection, Level, error) {
+	var locked = lockfile.Collection{
+		Name:    collection.Name,
+		Source:  collection.Source,
+		Type:    string(collection.Type),
+		Version: collection.Version,
+	}
+
+	switch collection.Type {
+	case types.CollectionTypeGalaxy, "":
+		release, err := l.limiter.acquire(ctx, ansibleGalaxy)
+		if err != nil {
+			return lockfile.Collection{}, LevelError, err
+		}
+		defer release()
+
+		var p = l.collectionsProviders[ansibleGalaxy]
+		artifacts, ok := p.(provider.CollectionArtifactsProvider)
+		if !ok {
+			return lockfile.Collection{}, LevelError, fmt.Errorf("locking collections is not supported by the %s provider", ansibleGalaxy)
+		}
+
+		// collections constrained to a range of versions,
+		// or not pinned at all, are locked to the latest
+		// version ansible-galaxy would install
+		constraints, isRange := versionConstraints(collection.Version)
+		if isRange {
+			versions, err := p.VersionsForCollection(ctx, collection)
+			if err != nil {
+				return lockfile.Collection{}, LevelError, err
+			}
+			locked.Version = latestMatchingVersion(versions, constraints)
+			if len(locked.Version) == 0 {
+				return lockfile.Collection{}, LevelError, errors.NewCollectionVersionNotFoundError(collection, versions)
+			}
+		}
+
+		exact := collection
+		exact.Version = locked.Version
+		artifact, err := artifacts.CollectionArtifact(ctx, exact)
+		if err != nil {
+			return lockfile.Collection{}, LevelError, err
+		}
+		locked.Checksum = lockfile.Checksum(artifact.Checksum)
+	case types.CollectionTypeGit:
+		// collections hosted on Git repositories are versioned
+		// exactly as roles, so we can rely on the roles provider
+		p, ok := l.rolesProviders[git].(commitProvider)
+		if !ok {
+			return lockfile.Collection{}, LevelError, fmt.Errorf("locking collections is not supported by the %s provider", git)
+		}
+		release, err := l.limiter.acquire(ctx, git)
+		if err != nil {
+			return lockfile.Collection{}, LevelError, err
+		}
+		defer release()
+		commit, err := p.CommitForVersion(ctx, types.Role{
+			Name:    collection.Name,
+			Source:  strings.TrimPrefix(collection.Name, "git+"),
+			Scm:     git,
+			Version: collection.Version,
+		}, collection.Version)
+		if err != nil {
+			return lockfile.Collection{}, LevelError, err
+		}
+		locked.Commit = commit
+	case types.CollectionTypeURL, types.CollectionTypeFile, types.CollectionTypeDir:
+		// we can't lock tarballs or local directories
+		return lockfile.Collection{}, LevelInfo, fmt.Errorf("unable to lock collections of type %s", collection.Type)
+	default:
+		return lockfile.Collection{}, LevelError, errors.NewUnknownCollectionTypeError(collection.Type)
+	}
+	return locked, "", nil
+}
+
+// LockLinter checks that the roles and collections of the requirements
+// files are locked by a Lockfile, and that their locked versions have not
+// changed upstream since the Lockfile has been written, as when a tag has
+// been moved to a different commit or the archive of a version has been
+// replaced, so that installing them would not be reproducible.
+type LockLinter struct {
+	locker *Locker
+	lock   *lockfile.Lockfile
+}
+
+// NewLockLinter returns a new LockLinter verifying the given
+// Lockfile, by resolving the locked versions again with the
+// given Locker.
+func NewLockLinter(locker *Locker, lock *lockfile.Lockfile) *LockLinter {
+	return &LockLinter{
+		locker: locker,
+		lock:   lock,
+	}
+}
+
+// Lint checks the locked versions of the Roles and Collections defined in the
+// given Requirements, and in all the Requirements files they include. A Result
+// with LevelError is sent on the output channel for each role or collection
+// which is not locked, with a NotLockedError, or which has changed upstream,
+// with a LockMismatchError. Roles and Collections which cannot be locked are
+// reported as done by the Locker, while no Result is sent for the others.
+// Results are sent in the same order the Roles and Collections are declared
+// in the Requirements.
+func (l *LockLinter) Lint(ctx context.Context, requirements *types.Requirements, output chan<- Result) error {
+	// make sure to close the results chan on exit
+	defer close(output)
+
+	var roles, collections = requirementsOf(requirements)
+
+	var results = make([]*Result, len(roles)+len(collections))
+	forEach(ctx, l.locker.parallelism, len(roles)+len(collections), func(i int) {
+		if i < len(roles) {
+			results[i] = l.verifyRole(ctx, roles[i])
+		} else {
+			results[i] = l.verifyCollection(ctx, collections[i-len(roles)])
+		}
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	for _, res := range results {
+		if res == nil {
+			continue
+		}
+		res.Linter = "lock"
+		select {
+		case <-ctx.Done():
+			return nil
+		case output <- *res:
+		}
+	}
+	return nil
+}
+
+// verifyRole checks the locked version of the given Role,
+// returning the Result to be reported, if any.
+func (l *LockLinter) verifyRole(ctx context.Context, role types.Role) *Result {
+	if _, level, err := roleScm(role); err != nil {
+		_, level, err = l.locker.lockRole(ctx, role)
+		return &Result{Role: role, Level: level, Err: err}
+	}
+
+	locked, ok := l.lock.FindRole(role)
+	switch {
+	case !ok:
+		return &Result{Role: role, Level: LevelError, Err: errors.NewNotLockedError("role", roleName(role), "")}
+	case len(role.Version) != 0 && role.Version != locked.Version:
+		return &Result{Role: role, Level: LevelError, Err: errors.NewNotLockedError("role", roleName(role), locked.Version)}
+	}
+
+	var lockedRole = role
+	lockedRole.Version = locked.Version
+	current, level, err := l.locker.lockRole(ctx, lockedRole)
+	if err != nil {
+		return &Result{Role: role, Level: level, Err: err}
+	}
+	if err := lockMismatch("role", roleName(role), locked.Version, locked.Commit, current.Commit, locked.Checksum, current.Checksum); err != nil {
+		return &Result{Role: role, Level: LevelError, Err: err}
+	}
+	return nil
+}
+
+// verifyCollection checks the locked version of the given
+// Collection, returning the Result to be reported, if any.
+func (l *LockLinter) verifyCollection(ctx context.Context, collection types.Collection) *Result {
+	switch collection.Type {
+	case types.CollectionTypeGalaxy, types.CollectionTypeGit, "":
+	default:
+		_, level, err := l.locker.lockCollection(ctx, collection)
+		return &Result{Collection: collection, Level: level, Err: err}
+	}
+
+	locked, ok := l.lock.FindCollection(collection)
+	if !ok {
+		return &Result{Collection: collection, Level: LevelError, Err: errors.NewNotLockedError("collection", collection.Name, "")}
+	}
+	if !allowsVersion(collection, locked.Version) {
+		return &Result{Collection: collection, Level: LevelError, Err: errors.NewNotLockedError("collection", collection.Name, locked.Version)}
+	}
+
+	var lockedCollection = collection
+	lockedCollection.Version = locked.Version
+	current, level, err := l.locker.lockCollection(ctx, lockedCollection)
+	if err != nil {
+		return &Result{Collection: collection, Level: level, Err: err}
+	}
+	if err := lockMismatch("collection", collection.Name, locked.Version, locked.Commit, current.Commit, locked.Checksum, current.Checksum); err != nil {
+		return &Result{Collection: collection, Level: LevelError, Err: err}
+	}
+	return nil
+}
+
+// allowsVersion checks whether the version constraints of
+// the given Collection are satisfied by the version v.
+func allowsVersion(collection types.Collection, v string) bool {
+	constraints, isRange := versionConstraints(collection.Version)
+	switch {
+	case isRange:
+		parsed, err := version.NewVersion(v)
+		return err == nil && constraints.Check(parsed)
+	default:
+		return collection.Version == v
+	}
+}
+
+// lockMismatch returns a LockMismatchError if the current
+// commit or checksum of a version differ from the locked ones.
+func lockMismatch(kind, name, version, lockedCommit, commit, lockedChecksum, checksum string) error {
+	if len(version) == 0 {
+		// roles hosted on Git repositories without
+		// a version are locked to their HEAD
+		version = "HEAD"
+	}
+	switch {
+	case lockedCommit != commit:
+		return errors.NewLockMismatchError(kind, name, version, "commit", lockedCommit, commit)
+	case lockedChecksum != checksum:
+		return errors.NewLockMismatchError(kind, name, version, "checksum", lockedChecksum, checksum)
+	default:
+		return nil
+	}
+}
+
+// requirementsOf returns the Roles and Collections defined in the
+// given Requirements, and in all the Requirements files they include,
+// in the order they are declared.
+func requirementsOf(requirements *types.Requirements) ([]types.Role, []types.Collection) {
+	var roles []types.Role
+	var collections []types.Collection
+	requirements.Walk(func(r *types.Requirements) error {
+		for _, role := range r.Roles {
+			if len(role.Include) == 0 {
+				roles = append(roles, role)
+			}
+		}
+		collections = append(collections, r.Collections...)
+		return nil
+	})
+	return roles, collections
+}
@@ -0,0 +1,221 @@
+package linter
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/lockfile"
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+	version "github.com/hashicorp/go-version"
+)
+
+// mockLockProvider resolves the roles and collections in its
+// map, indexed by name@version, to the commit or checksum of
+// their version. The name@ entries hold the HEAD commit of the
+// Git roles.
+type mockLockProvider map[string]string
+
+// mockLockGit resolves the versions of the
+// roles to the commits of the mockLockProvider.
+type mockLockGit mockLockProvider
+
+func (g mockLockGit) VersionsForRole(ctx context.Context, r types.Role) ([]string, error) {
+	return nil, errors.NewRoleNotFoundError(r, "mockLockGit")
+}
+
+func (g mockLockGit) CommitForVersion(ctx context.Context, r types.Role, version string) (string, error) {
+	if commit, ok := g[roleName(r)+"@"+version]; ok {
+		return commit, nil
+	}
+	return "", errors.NewRoleVersionNotFoundError(r, nil)
+}
+
+// mockLockGalaxy resolves the roles and collections to
+// the archives checksums of the mockLockProvider.
+type mockLockGalaxy mockLockProvider
+
+func (g mockLockGalaxy) VersionsForRole(ctx context.Context, r types.Role) ([]string, error) {
+	var versions []string
+	for key := range g {
+		if strings.HasPrefix(key, roleName(r)+"@") {
+			versions = append(versions, strings.TrimPrefix(key, roleName(r)+"@"))
+		}
+	}
+	if len(versions) == 0 {
+		return nil, errors.NewRoleNotFoundError(r, "mockLockGalaxy")
+	}
+	return versions, nil
+}
+
+func (g mockLockGalaxy) RoleArtifact(ctx context.Context, r types.Role) (provider.Artifact, error) {
+	if checksum, ok := g[roleName(r)+"@"+r.Version]; ok {
+		return provider.Artifact{Version: r.Version, Checksum: checksum}, nil
+	}
+	return provider.Artifact{}, errors.NewRoleVersionNotFoundError(r, nil)
+}
+
+func (g mockLockGalaxy) VersionsForCollection(ctx context.Context, c types.Collection) ([]string, error) {
+	return []string{"1.0.0", "1.1.0", "2.0.0", "2.1.0-rc1"}, nil
+}
+
+func (g mockLockGalaxy) CollectionArtifact(ctx context.Context, c types.Collection) (provider.Artifact, error) {
+	if checksum, ok := g[c.Name+"@"+c.Version]; ok {
+		return provider.Artifact{Version: c.Version, Checksum: checksum}, nil
+	}
+	return provider.Artifact{}, errors.NewCollectionVersionNotFoundError(c, nil)
+}
+
+func newMockLocker(p mockLockProvider) *Locker {
+	return &Locker{
+		rolesProviders: map[string]provider.RolesProvider{
+			git:           mockLockGit(p),
+			ansibleGalaxy: mockLockGalaxy(p),
+		},
+		collectionsProviders: map[string]provider.CollectionsProvider{
+			ansibleGalaxy: mockLockGalaxy(p),
+		},
+		policy: DefaultUpdatePolicy,
+	}
+}
+
+func TestLocker(t *testing.T) {
+	p := mockLockProvider{
+		"test.role@v1.0.0":                            "0123",
+		"test.role@v1.1.0":                            "abcd",
+		"test.role@v2.0.0-rc1":                        "ef01",
+		"https://github.com/test/ansible-role@v1.0.0": "5f1e2d",
+		"https://github.com/test/ansible-role@":       "c0ffee",
+		"test.collection@1.1.0":                       "1234",
+		"test.collection@2.0.0":                       "5678",
+	}
+	requirements := &types.Requirements{
+		Roles: []types.Role{
+			{Name: "test.role"},
+			{Source: "https://github.com/test/ansible-role", Scm: "git", Version: "v1.0.0"},
+			{Name: "test.archive", Source: "https://example.com/test.tar.gz"},
+			{Name: "test.missing", Version: "v1.0.0"},
+		},
+		Childrens: []*types.Requirements{{
+			Roles: []types.Role{
+				{Source: "https://github.com/test/ansible-role"},
+			},
+			Collections: []types.Collection{
+				// pre-releases are not locked unless required
+				{Name: "test.collection"},
+				{Name: "test.collection", Version: ">=1.0.0,<2.0.0"},
+				{Name: "test.dir", Type: types.CollectionTypeDir},
+			},
+		}},
+	}
+
+	lock, results := newMockLocker(p).Lock(context.Background(), requirements)
+
+	expected := &lockfile.Lockfile{
+		Roles: []lockfile.Role{
+			{Name: "test.role", Version: "v1.1.0", Checksum: "sha256:abcd"},
+			{Source: "https://github.com/test/ansible-role", Scm: "git", Version: "v1.0.0", Commit: "5f1e2d"},
+			{Source: "https://github.com/test/ansible-role", Commit: "c0ffee"},
+		},
+		Collections: []lockfile.Collection{
+			{Name: "test.collection", Version: "2.0.0", Checksum: "sha256:5678"},
+			{Name: "test.collection", Version: "1.1.0", Checksum: "sha256:1234"},
+		},
+	}
+	if !reflect.DeepEqual(expected, lock) {
+		t.Errorf("expecting lock file %+v, obtained %+v", expected, lock)
+	}
+
+	var obtained []string
+	for _, res := range results {
+		obtained = append(obtained, fmt.Sprintf("%s%s %s", roleName(res.Role), res.Collection.Name, res.Level))
+	}
+	if expected := []string{"test.archive INFO", "test.missing ERR", "test.dir INFO"}; !reflect.DeepEqual(expected, obtained) {
+		t.Errorf("expecting results %v, obtained %v", expected, obtained)
+	}
+
+	// the Galaxy roles without a version are locked
+	// to the latest version allowed by the UpdatePolicy
+	constraints, _ := ParseConstraints("<1.1.0")
+	for name, c := range map[string]struct {
+		policy  UpdatePolicy
+		version string
+	}{
+		"pre-releases": {policy: UpdatePolicy{MaxBump: BumpMajor, PreReleases: true}, version: "v2.0.0-rc1"},
+		"constraints":  {policy: UpdatePolicy{MaxBump: BumpMajor, Constraints: map[string]version.Constraints{"test.role": constraints}}, version: "v1.0.0"},
+	} {
+		locker := newMockLocker(p)
+		locker.WithUpdatePolicy(c.policy)
+		lock, results := locker.Lock(context.Background(), &types.Requirements{Roles: []types.Role{{Name: "test.role"}}})
+		if len(results) != 0 || len(lock.Roles) != 1 || lock.Roles[0].Version != c.version {
+			t.Errorf("%s: expecting test.role locked to %s, obtained %+v %v", name, c.version, lock.Roles, results)
+		}
+	}
+}
+
+func TestLockLinter(t *testing.T) {
+	lock := &lockfile.Lockfile{
+		Roles: []lockfile.Role{
+			{Name: "test.role", Version: "v1.1.0", Checksum: "sha256:abcd"},
+			{Name: "test.moved", Source: "git@github.com:test/ansible-moved.git", Version: "v1.0.0", Commit: "5f1e2d"},
+			{Name: "test.old", Version: "v1.0.0", Checksum: "sha256:0000"},
+			{Name: "test.twice", Version: "v1.0.0", Checksum: "sha256:aaaa"},
+			{Name: "test.twice", Version: "v2.0.0", Checksum: "sha256:bbbb"},
+		},
+		Collections: []lockfile.Collection{
+			{Name: "test.collection", Version: "1.1.0", Checksum: "sha256:1234"},
+			{Name: "test.replaced", Version: "1.0.0", Checksum: "sha256:1234"},
+		},
+	}
+	p := mockLockProvider{
+		"test.role@v1.1.0":  "abcd",
+		"test.twice@v1.0.0": "aaaa",
+		"test.twice@v2.0.0": "bbbb",
+		// the tag has been moved to a different commit
+		"git+git@github.com:test/ansible-moved@v1.0.0": "e4d3c2",
+		"test.collection@1.1.0":                        "1234",
+		// the archive has been replaced
+		"test.replaced@1.0.0": "5678",
+	}
+	requirements := &types.Requirements{
+		Roles: []types.Role{
+			// the roles not pinned to a version
+			// are verified at the locked version
+			{Name: "test.role"},
+			{Source: "git+git@github.com:test/ansible-moved", Scm: "git", Version: "v1.0.0"},
+			{Name: "test.old", Version: "v2.0.0"},
+			{Name: "test.unlocked", Version: "v1.0.0"},
+			{Name: "test.twice", Version: "v1.0.0"},
+		},
+		Collections: []types.Collection{
+			{Name: "test.collection", Version: ">=1.0.0"},
+			{Name: "test.replaced", Version: "1.0.0"},
+		},
+		// the role is locked at a different
+		// version by an included file
+		Childrens: []*types.Requirements{{
+			Roles: []types.Role{{Name: "test.twice", Version: "v2.0.0"}},
+		}},
+	}
+
+	results := make(chan Result)
+	go NewLockLinter(newMockLocker(p), lock).Lint(context.Background(), requirements, results)
+
+	var obtained []string
+	for res := range results {
+		obtained = append(obtained, fmt.Sprintf("%s%s %s %v", roleName(res.Role), res.Collection.Name, res.Level, res.Err))
+	}
+	expected := []string{
+		"git+git@github.com:test/ansible-moved ERR version v1.0.0 of role git+git@github.com:test/ansible-moved has changed upstream: the commit is e4d3c2, but 5f1e2d is locked",
+		"test.old ERR role test.old is locked at version v1.0.0, which does not match the requirements file, lock the requirements again",
+		"test.unlocked ERR role test.unlocked is not in the lock file, lock the requirements again",
+		"test.replaced ERR version 1.0.0 of collection test.replaced has changed upstream: the checksum is sha256:5678, but sha256:1234 is locked",
+	}
+	if !reflect.DeepEqual(expected, obtained) {
+		t.Errorf("expecting results %v, obtained %v", expected, obtained)
+	}
+}
@@ -0,0 +1,345 @@
+package linter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/cache"
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/parser"
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+// Pin is the kind of reference the version of a role is pinned to.
+type Pin string
+
+const (
+	// PinSemverTag is used for versions which are tags
+	// in the semantic versioning format (e.g. v1.2.3).
+	PinSemverTag = Pin("semver-tag")
+
+	// PinTag is used for versions which are tags
+	// not in the semantic versioning format.
+	PinTag = Pin("tag")
+
+	// PinBranch is used for versions which are branches
+	// of the Git repository of the role, or its HEAD.
+	PinBranch = Pin("branch")
+
+	// PinCommit is used for versions which are
+	// full hashes of the commits of the Git
+	// repository of the role.
+	PinCommit = Pin("commit")
+
+	// PinShortCommit is used for versions which are
+	// abbreviated hashes of the commits of the Git
+	// repository of the role.
+	PinShortCommit = Pin("short-commit")
+
+	// PinMissing is used for roles
+	// not pinned to any version.
+	PinMissing = Pin("missing")
+
+	// PinUnknown is used for versions which
+	// cannot be found or classified.
+	PinUnknown = Pin("unknown")
+)
+
+// pins is the list of the kinds of references
+// which can be allowed by a PinningPolicy.
+var pins = []Pin{PinSemverTag, PinTag, PinBranch, PinCommit, PinShortCommit, PinMissing}
+
+// Description returns a human readable
+// description of the kind of reference.
+func (p Pin) Description() string {
+	switch p {
+	case PinSemverTag:
+		return "semantic version tag"
+	case PinTag:
+		return "tag"
+	case PinBranch:
+		return "branch"
+	case PinCommit:
+		return "commit"
+	case PinShortCommit:
+		return "abbreviated commit"
+	case PinMissing:
+		return "missing version"
+	default:
+		return "unknown version"
+	}
+}
+
+// PinningPolicy is the list of the kinds
+// of references roles are allowed to be pinned to.
+type PinningPolicy []Pin
+
+// DefaultPinningPolicy allows roles to be pinned to tags
+// and commits, but not to branches, nor to be unpinned.
+var DefaultPinningPolicy = PinningPolicy{PinSemverTag, PinTag, PinCommit, PinShortCommit}
+
+// ParsePinningPolicy parses a comma separated list of the kinds
+// of references roles are allowed to be pinned to (e.g. semver-tag,commit).
+func ParsePinningPolicy(s string) (PinningPolicy, error) {
+	var policy PinningPolicy
+	for _, name := range strings.Split(s, ",") {
+		var pin = Pin(strings.TrimSpace(name))
+		if !containsPin(pins, pin) {
+			return nil, fmt.Errorf("unknown kind of version %s, allowed values are %s", pin, PinningPolicy(pins))
+		}
+		policy = append(policy, pin)
+	}
+	return policy, nil
+}
+
+// Allows checks whether roles are allowed to be
+// pinned to the given kind of reference.
+func (p PinningPolicy) Allows(pin Pin) bool {
+	return pin == PinUnknown || containsPin(p, pin)
+}
+
+// String returns the PinningPolicy as a comma separated
+// list, in the format accepted by ParsePinningPolicy.
+func (p PinningPolicy) String() string {
+	var names = make([]string, len(p))
+	for i, pin := range p {
+		names[i] = string(pin)
+	}
+	return strings.Join(names, ",")
+}
+
+// containsPin checks whether pin is part
+// of the provided list of kinds of references.
+func containsPin(pins []Pin, pin Pin) bool {
+	for _, p := range pins {
+		if p == pin {
+			return true
+		}
+	}
+	return false
+}
+
+// branchesProvider is implemented by the RolesProviders listing
+// the branches of the roles, such as provider.Git.
+type branchesProvider interface {
+	BranchesForRole(ctx context.Context, r types.Role) ([]string, error)
+}
+
+// PinningLinter checks that the roles are pinned to the kinds of
+// references allowed by a PinningPolicy. Roles pinned to branches,
+// or not pinned at all, are installed at whatever commit the branch
+// points to at install time, so that installs are not reproducible.
+type PinningLinter struct {
+	policy PinningPolicy
+
+	// cache, if set, stores the versions
+	// fetched by the providers
+	cache *cache.Cache
+
+	// offline, if true, prevents the branches
+	// of the roles from being listed
+	offline bool
+
+	// galaxyURLs are the URLs of the Ansible Galaxy
+	// servers queried by the galaxy providers
+	galaxyURLs []string
+
+	// rolesProviders are defined as attribute of the
+	// PinningLinter struct to allow mocking during unit tests
+	rolesProviders map[string]provider.RolesProvider
+
+	// parallelism is the number of
+	// roles checked concurrently
+	parallelism int
+
+	// limiter limits the number of concurrent
+	// lookups sent to each provider
+	limiter *Limiter
+}
+
+// NewPinningLinter returns a new PinningLinter
+// applying the given PinningPolicy.
+func NewPinningLinter(policy PinningPolicy) *PinningLinter {
+	p := &PinningLinter{
+		policy: policy,
+		rolesProviders: map[string]provider.RolesProvider{
+			git: provider.NewGit(),
+		},
+		parallelism: DefaultParallelism,
+		limiter:     NewLimiter(DefaultGalaxyConcurrency, DefaultGitConcurrency),
+	}
+	p.WithGalaxyServers(provider.GalaxyServer{URL: provider.DefaultAnsibleGalaxyURL})
+	return p
+}
+
+// WithGalaxyServers configures the PinningLinter to look up the
+// versions of the roles on the given Ansible Galaxy servers, queried
+// in order until one hosting the role is found.
+func (p *PinningLinter) WithGalaxyServers(servers ...provider.GalaxyServer) {
+	p.rolesProviders[ansibleGalaxy] = provider.NewGalaxyServers(servers...)
+
+	p.galaxyURLs = nil
+	for _, s := range servers {
+		p.galaxyURLs = append(p.galaxyURLs, s.URL)
+	}
+}
+
+// WithGitCredentials configures the PinningLinter to use the given
+// credentials to access the Git repositories of each Git server.
+func (p *PinningLinter) WithGitCredentials(credentials ...provider.GitCredentials) {
+	p.rolesProviders[git] = provider.NewGit(credentials...)
+}
+
+// WithCache configures the PinningLinter to look up the
+// versions of the roles in the given Cache before querying
+// the providers, as done by the UpdatesLinter.
+func (p *PinningLinter) WithCache(c *cache.Cache) {
+	p.cache = c
+}
+
+// WithOffline configures the PinningLinter not to list the
+// branches of the roles, which are not stored in the cache.
+// Versions which are neither tags nor commits are not checked.
+func (p *PinningLinter) WithOffline() {
+	p.offline = true
+}
+
+// WithParallelism configures the number of roles
+// checked concurrently by the PinningLinter.
+func (p *PinningLinter) WithParallelism(n int) {
+	p.parallelism = n
+}
+
+// WithLimiter configures the Limiter limiting the number
+// of concurrent lookups sent by the PinningLinter to each
+// provider, instead of the default limits.
+func (p *PinningLinter) WithLimiter(l *Limiter) {
+	p.limiter = l
+}
+
+// Lint classifies the versions of the Roles defined in the given Requirements,
+// and in all the Requirements files they include. A Result with LevelWarning
+// and an UnpinnedVersionError is sent on the output channel for each role pinned
+// to a kind of reference not allowed by the PinningPolicy, with the Metadata
+// field set to the Pin of the role. Versions which cannot be found, or whose
+// versions cannot be fetched, are not reported, as they are reported by the
+// UpdatesLinter. Results are sent in the same order the Roles are declared
+// in the Requirements.
+func (p *PinningLinter) Lint(ctx context.Context, requirements *types.Requirements, output chan<- Result) error {
+	// make sure to close the results chan on exit
+	defer close(output)
+
+	var roles, _ = requirementsOf(requirements)
+
+	var results = make([]*Result, len(roles))
+	forEach(ctx, p.parallelism, len(roles), func(i int) {
+		pin := p.Classify(ctx, roles[i])
+		if p.policy.Allows(pin) {
+			return
+		}
+
+		var allowed = make([]string, len(p.policy))
+		for j, a := range p.policy {
+			allowed[j] = a.Description()
+		}
+		results[i] = &Result{
+			Role:     roles[i],
+			Level:    LevelWarning,
+			Err:      errors.NewUnpinnedVersionError(roleName(roles[i]), roles[i].Version, pin.Description(), allowed),
+			Metadata: pin,
+		}
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	for _, res := range results {
+		if res == nil {
+			continue
+		}
+		res.Linter = "pinning"
+		select {
+		case <-ctx.Done():
+			return nil
+		case output <- *res:
+		}
+	}
+	return nil
+}
+
+// Classify returns the kind of reference the given Role is pinned to.
+// Versions are classified as tags when they are among the versions
+// of the role, as semantic version tags when parsed as KindSemver
+// Versions, and as commits when they look like commit hashes.
+// The branches of the Git repositories are only listed for the
+// versions which are neither tags nor commits.
+func (p *PinningLinter) Classify(ctx context.Context, role types.Role) Pin {
+	if len(role.Version) == 0 {
+		return PinMissing
+	}
+
+	scm, _, err := roleScm(role)
+	if err != nil {
+		return PinUnknown
+	}
+
+	release, err := p.limiter.acquire(ctx, scm)
+	if err != nil {
+		return PinUnknown
+	}
+	defer release()
+
+	var rolesProvider = p.rolesProviders[scm]
+	if p.cache != nil {
+		rolesProvider = p.cache.RolesProvider(cacheName(scm, p.galaxyURLs), rolesProvider)
+	}
+	versions, err := rolesProvider.VersionsForRole(ctx, role)
+	if err != nil {
+		return PinUnknown
+	}
+
+	switch {
+	case containsVersion(versions, role.Version) && isSemver(role.Version):
+		return PinSemverTag
+	case containsVersion(versions, role.Version):
+		return PinTag
+	case scm != git:
+		// Ansible Galaxy only serves tagged releases
+		return PinUnknown
+	case role.Version == "HEAD":
+		return PinBranch
+	case parser.IsCommitHash(role.Version) && len(role.Version) == 40:
+		return PinCommit
+	case parser.IsCommitHash(role.Version):
+		return PinShortCommit
+	case p.offline:
+		return PinUnknown
+	case isBranch(ctx, p.rolesProviders[git], role):
+		return PinBranch
+	default:
+		return PinUnknown
+	}
+}
+
+// isSemver checks whether the given tag, once its
+// TagPrefix is removed, is a KindSemver Version.
+func isSemver(tag string) bool {
+	v, err := ParseVersion(tag, TagPrefix(tag))
+	return err == nil && v.Kind == KindSemver
+}
+
+// isBranch checks whether the version of the given Role is
+// one of the branches listed by p, or the HEAD of the repository.
+func isBranch(ctx context.Context, p provider.RolesProvider, role types.Role) bool {
+	if role.Version == "HEAD" {
+		return true
+	}
+	branchesProvider, ok := p.(branchesProvider)
+	if !ok {
+		return false
+	}
+	branches, err := branchesProvider.BranchesForRole(ctx, role)
+	return err == nil && containsVersion(branches, role.Version)
+}
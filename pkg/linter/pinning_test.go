@@ -0,0 +1,124 @@
+package linter
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+// mockPinningProvider returns the same tags and
+// branches for all the roles but the ones named
+// notfound, which are not found.
+type mockPinningProvider struct{}
+
+func (m mockPinningProvider) VersionsForRole(ctx context.Context, r types.Role) ([]string, error) {
+	if roleName(r) == "test.notfound" {
+		return nil, errors.NewRoleNotFoundError(r, "mockPinningProvider")
+	}
+	return []string{"v1.0.0", "1.1.0", "v1.2", "stable", "2020.01.15", "myrole-v1.2.0"}, nil
+}
+
+func (m mockPinningProvider) BranchesForRole(ctx context.Context, r types.Role) ([]string, error) {
+	return []string{"develop", "master"}, nil
+}
+
+func TestPinningLinterClassify(t *testing.T) {
+	p := &PinningLinter{
+		rolesProviders: map[string]provider.RolesProvider{
+			git:           mockPinningProvider{},
+			ansibleGalaxy: mockPinningProvider{},
+		},
+	}
+
+	var source = "https://github.com/test/ansible-role"
+	testCases := map[string]struct {
+		role     types.Role
+		expected Pin
+	}{
+		"git:semverTag":      {role: types.Role{Source: source, Scm: "git", Version: "v1.0.0"}, expected: PinSemverTag},
+		"git:shortSemverTag": {role: types.Role{Source: source, Scm: "git", Version: "v1.2"}, expected: PinSemverTag},
+		"git:tag":            {role: types.Role{Source: source, Scm: "git", Version: "stable"}, expected: PinTag},
+		"git:dateTag":        {role: types.Role{Source: source, Scm: "git", Version: "2020.01.15"}, expected: PinTag},
+		"git:prefixedTag":    {role: types.Role{Source: source, Scm: "git", Version: "myrole-v1.2.0"}, expected: PinSemverTag},
+		"git:branch":         {role: types.Role{Source: source, Scm: "git", Version: "master"}, expected: PinBranch},
+		"git:head":           {role: types.Role{Source: source, Scm: "git", Version: "HEAD"}, expected: PinBranch},
+		"git:commit":         {role: types.Role{Source: source, Scm: "git", Version: "5f1e2d3c4b5a69788796a5b4c3d2e1f0a1b2c3d4"}, expected: PinCommit},
+		"git:shortCommit":    {role: types.Role{Source: source, Scm: "git", Version: "5f1e2d3"}, expected: PinShortCommit},
+		"git:missing":        {role: types.Role{Source: source, Scm: "git"}, expected: PinMissing},
+		"git:unknown":        {role: types.Role{Source: source, Scm: "git", Version: "feature"}, expected: PinUnknown},
+		"galaxy:semverTag":   {role: types.Role{Name: "test.role", Version: "1.1.0"}, expected: PinSemverTag},
+		"galaxy:unknown":     {role: types.Role{Name: "test.role", Version: "master"}, expected: PinUnknown},
+		"galaxy:notFound":    {role: types.Role{Name: "test.notfound", Version: "v1.0.0"}, expected: PinUnknown},
+		"unknownScm:commit":  {role: types.Role{Source: source, Scm: "hg", Version: "5f1e2d3"}, expected: PinUnknown},
+	}
+
+	for name, tc := range testCases {
+		if obtained := p.Classify(context.Background(), tc.role); obtained != tc.expected {
+			t.Errorf("%s: expecting %s, obtained %s", name, tc.expected, obtained)
+		}
+	}
+
+	// branches are not listed in offline mode
+	p.WithOffline()
+	if obtained := p.Classify(context.Background(), types.Role{Source: source, Scm: "git", Version: "master"}); obtained != PinUnknown {
+		t.Errorf("offline: expecting %s, obtained %s", PinUnknown, obtained)
+	}
+}
+
+func TestPinningLinter(t *testing.T) {
+	p := &PinningLinter{
+		policy: PinningPolicy{PinSemverTag, PinCommit},
+		rolesProviders: map[string]provider.RolesProvider{
+			git:           mockPinningProvider{},
+			ansibleGalaxy: mockPinningProvider{},
+		},
+	}
+
+	requirements := &types.Requirements{
+		Roles: []types.Role{
+			{Name: "test.role", Version: "v1.0.0"},
+			{Name: "test.missing"},
+			{Source: "https://github.com/test/ansible-role", Scm: "git", Version: "master"},
+		},
+		Childrens: []*types.Requirements{{
+			Roles: []types.Role{
+				{Source: "https://github.com/test/ansible-role", Scm: "git", Version: "5f1e2d3"},
+				{Source: "https://github.com/test/ansible-role", Scm: "git", Version: "feature"},
+			},
+		}},
+	}
+
+	results := make(chan Result)
+	go p.Lint(context.Background(), requirements, results)
+
+	var obtained []string
+	for res := range results {
+		obtained = append(obtained, fmt.Sprintf("%s %s %s %v", roleName(res.Role), res.Level, res.Metadata, res.Err))
+	}
+	expected := []string{
+		"test.missing WARN missing role test.missing is not pinned to any version, while the pinning policy requires a semantic version tag or commit",
+		"https://github.com/test/ansible-role WARN branch role https://github.com/test/ansible-role is pinned to the branch master, while the pinning policy requires a semantic version tag or commit",
+		"https://github.com/test/ansible-role WARN short-commit role https://github.com/test/ansible-role is pinned to the abbreviated commit 5f1e2d3, while the pinning policy requires a semantic version tag or commit",
+	}
+	if !reflect.DeepEqual(expected, obtained) {
+		t.Errorf("expecting results %v, obtained %v", expected, obtained)
+	}
+}
+
+func TestParsePinningPolicy(t *testing.T) {
+	policy, err := ParsePinningPolicy("semver-tag, commit")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if expected := (PinningPolicy{PinSemverTag, PinCommit}); !reflect.DeepEqual(expected, policy) {
+		t.Errorf("expecting policy %v, obtained %v", expected, policy)
+	}
+	if _, err := ParsePinningPolicy("semver-tag,unknown"); err == nil {
+		t.Errorf("expecting an error parsing an unknown kind of version")
+	}
+}
@@ -0,0 +1,150 @@
+package linter
+
+import (
+	"strings"
+
+	version "github.com/hashicorp/go-version"
+)
+
+// UpdatePolicy configures which of the versions of the roles
+// and collections are reported as updates by the UpdatesLinter.
+type UpdatePolicy struct {
+	// MaxBump is the largest version increment reported as an
+	// update. Newer versions incrementing the version further
+	// (e.g. the major version, when MaxBump is BumpMinor) are
+	// only reported as the latest version overall, with LevelInfo.
+	MaxBump Bump
+
+	// PreReleases, if true, allows updates to pre-release
+	// versions (e.g. v1.0.0-rc1), ignored by default.
+	PreReleases bool
+
+	// Constraints holds the version constraints (e.g. ~> 2.3 or
+	// >=1.2,<2.0) the updates to the roles and collections, keyed
+	// by name or source, are required to satisfy.
+	Constraints map[string]version.Constraints
+
+	// TagPrefixes holds the prefixes preceding the versions in the
+	// tags of the roles, keyed by name or source, as used by the
+	// repositories hosting multiple roles (e.g. myrole- for the tags
+	// myrole-1.2.3). When not configured, the prefix of the version
+	// the role is pinned to is used.
+	TagPrefixes map[string]string
+}
+
+// DefaultUpdatePolicy reports the updates to the latest
+// version, regardless of the version increment, ignoring
+// pre-releases.
+var DefaultUpdatePolicy = UpdatePolicy{MaxBump: BumpMajor}
+
+// ParseConstraints parses a comma separated list of version
+// constraints (e.g. >=1.2,<2.0), in the format accepted by
+// ansible-galaxy for the versions of collections.
+func ParseConstraints(s string) (version.Constraints, error) {
+	s = strings.TrimSpace(s)
+	if s == "*" {
+		// any version is accepted
+		s = ">= 0"
+	}
+
+	// ansible-galaxy accepts exact matches prefixed by
+	// the == operator, which is not supported by go-version
+	return version.NewConstraint(strings.Replace(s, "==", "=", -1))
+}
+
+// releases holds the versions of a role or collection
+// found among its tags by UpdatePolicy.releases.
+type releases struct {
+	// current is the version the role or collection
+	// is pinned to, nil if it is not a version
+	current *Version
+
+	// allowed and latest are the latest versions allowed by the
+	// UpdatePolicy and overall, nil if no version is found
+	allowed *Version
+	latest  *Version
+
+	// count is the number of versions found among the tags
+	count int
+
+	// ignored holds the tags which are not versions
+	ignored []string
+}
+
+// releases looks up, among the given tags of the role or collection with
+// the given names pinned to the version from, the latest version allowed
+// by the UpdatePolicy as an update and the latest version overall. Only
+// the tags with the prefix of the role are considered and, if from is a
+// version, only the tags following the same versioning scheme. Pre-releases
+// are ignored, unless allowed by the UpdatePolicy or equal to from.
+func (p UpdatePolicy) releases(tags []string, from string, names ...string) releases {
+	var prefix = p.tagPrefixFor(from, names...)
+	var constraints = p.constraintsFor(names...)
+
+	var r releases
+	var versions []*Version
+	versions, r.ignored = parseVersions(tags, prefix)
+	r.count = len(versions)
+	r.current, _ = ParseVersion(from, prefix)
+
+	for _, v := range versions {
+		switch {
+		case len(v.Prerelease()) != 0 && !p.PreReleases && v.Tag != from:
+			continue
+		case r.current != nil && v.Kind != r.current.Kind:
+			continue
+		}
+		if r.latest == nil || v.GreaterThan(r.latest) {
+			r.latest = v
+		}
+
+		switch {
+		case constraints != nil && !v.Check(constraints):
+			continue
+		case r.current != nil && v.GreaterThan(r.current) && versionBump(r.current, v) > p.MaxBump:
+			continue
+		}
+		if r.allowed == nil || v.GreaterThan(r.allowed) {
+			r.allowed = v
+		}
+	}
+	return r
+}
+
+// isUpdate checks whether the latest version allowed is more
+// recent than the version from. When from is not a version,
+// any different version allowed is considered more recent.
+func (r releases) isUpdate(from string) bool {
+	switch {
+	case r.allowed == nil:
+		return false
+	case r.current == nil:
+		return r.allowed.Tag != from
+	default:
+		return r.allowed.GreaterThan(r.current)
+	}
+}
+
+// constraintsFor returns the Constraints configured
+// for the first of the given names having any.
+func (p UpdatePolicy) constraintsFor(names ...string) version.Constraints {
+	for _, name := range names {
+		if c, ok := p.Constraints[name]; len(name) != 0 && ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// tagPrefixFor returns the prefix of the tags of the role or
+// collection with the given names: the one configured for the
+// first of the names having any or, if none, the one of the
+// version v.
+func (p UpdatePolicy) tagPrefixFor(v string, names ...string) string {
+	for _, name := range names {
+		if prefix, ok := p.TagPrefixes[name]; len(name) != 0 && ok {
+			return prefix
+		}
+	}
+	return TagPrefix(v)
+}
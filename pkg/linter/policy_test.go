@@ -0,0 +1,153 @@
+package linter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+	version "github.com/hashicorp/go-version"
+)
+
+func mustParseConstraints(t *testing.T, s string) version.Constraints {
+	c, err := ParseConstraints(s)
+	if err != nil {
+		t.Fatalf("unable to parse the constraints %s: %v", s, err)
+	}
+	return c
+}
+
+func TestUpdatePolicy(t *testing.T) {
+	versions := []string{"v1.0.0", "v1.0.1", "v1.1.0", "v2.0.0", "v2.3.0", "v2.4.0", "v3.0.0", "v3.1.0-rc1"}
+
+	cases := map[string]struct {
+		policy  UpdatePolicy
+		version string
+		level   Level
+		update  Update
+	}{
+		"default": {
+			policy:  DefaultUpdatePolicy,
+			version: "v1.0.0",
+			level:   LevelWarning,
+			update:  Update{FromVersion: "v1.0.0", ToVersion: "v3.0.0", LatestVersion: "v3.0.0", IsUpdate: true},
+		},
+		"patch": {
+			policy:  UpdatePolicy{MaxBump: BumpPatch},
+			version: "v1.0.0",
+			level:   LevelWarning,
+			update:  Update{FromVersion: "v1.0.0", ToVersion: "v1.0.1", LatestVersion: "v3.0.0", IsUpdate: true},
+		},
+		"minor": {
+			policy:  UpdatePolicy{MaxBump: BumpMinor},
+			version: "v1.0.0",
+			level:   LevelWarning,
+			update:  Update{FromVersion: "v1.0.0", ToVersion: "v1.1.0", LatestVersion: "v3.0.0", IsUpdate: true},
+		},
+		"minor:majorOnly": {
+			policy:  UpdatePolicy{MaxBump: BumpMinor},
+			version: "v2.4.0",
+			level:   LevelInfo,
+			update:  Update{FromVersion: "v2.4.0", ToVersion: "v2.4.0", LatestVersion: "v3.0.0", IsUpdate: false},
+		},
+		"preReleases": {
+			policy:  UpdatePolicy{MaxBump: BumpMajor, PreReleases: true},
+			version: "v2.4.0",
+			level:   LevelWarning,
+			update:  Update{FromVersion: "v2.4.0", ToVersion: "v3.1.0-rc1", LatestVersion: "v3.1.0-rc1", IsUpdate: true},
+		},
+		"preReleases:current": {
+			policy:  DefaultUpdatePolicy,
+			version: "v3.1.0-rc1",
+			level:   LevelInfo,
+			update:  Update{FromVersion: "v3.1.0-rc1", ToVersion: "v3.1.0-rc1", LatestVersion: "v3.1.0-rc1", IsUpdate: false},
+		},
+		"constraints:pessimistic": {
+			policy: UpdatePolicy{MaxBump: BumpMajor, Constraints: map[string]version.Constraints{
+				"test.role": mustParseConstraints(t, "~> 2.3"),
+			}},
+			version: "v1.0.0",
+			level:   LevelWarning,
+			update:  Update{FromVersion: "v1.0.0", ToVersion: "v2.4.0", LatestVersion: "v3.0.0", IsUpdate: true},
+		},
+		"constraints:range": {
+			policy: UpdatePolicy{MaxBump: BumpMajor, Constraints: map[string]version.Constraints{
+				"test.role": mustParseConstraints(t, ">=1.0,<2.0"),
+			}},
+			version: "v1.1.0",
+			level:   LevelInfo,
+			update:  Update{FromVersion: "v1.1.0", ToVersion: "v1.1.0", LatestVersion: "v3.0.0", IsUpdate: false},
+		},
+		"constraints:otherRole": {
+			policy: UpdatePolicy{MaxBump: BumpMajor, Constraints: map[string]version.Constraints{
+				"test.other": mustParseConstraints(t, ">=1.0,<2.0"),
+			}},
+			version: "v1.1.0",
+			level:   LevelWarning,
+			update:  Update{FromVersion: "v1.1.0", ToVersion: "v3.0.0", LatestVersion: "v3.0.0", IsUpdate: true},
+		},
+	}
+
+	for k, c := range cases {
+		res := checkRole(types.Role{Name: "test.role", Version: c.version}, versions, c.policy)
+		if res.Level != c.level {
+			t.Errorf("%s: expecting level %s, obtained %s", k, c.level, res.Level)
+		}
+		if !reflect.DeepEqual(res.Metadata, c.update) {
+			t.Errorf("%s: expecting update %+v, obtained %+v", k, c.update, res.Metadata)
+		}
+	}
+}
+
+func TestUpdatePolicyCollections(t *testing.T) {
+	versions := []string{"1.0.0", "1.1.0", "2.0.0", "2.1.0-beta"}
+	policy := UpdatePolicy{MaxBump: BumpMinor, Constraints: map[string]version.Constraints{
+		"test.pinned": mustParseConstraints(t, "==1.0.0"),
+	}}
+
+	cases := map[string]struct {
+		collection types.Collection
+		level      Level
+		update     Update
+	}{
+		"range": {
+			collection: types.Collection{Name: "test.collection", Version: ">=1.0.0,<1.1.0"},
+			level:      LevelWarning,
+			update:     Update{FromVersion: ">=1.0.0,<1.1.0", ToVersion: "2.0.0", LatestVersion: "2.0.0", IsUpdate: true},
+		},
+		"minor": {
+			collection: types.Collection{Name: "test.collection", Version: "1.0.0"},
+			level:      LevelWarning,
+			update:     Update{FromVersion: "1.0.0", ToVersion: "1.1.0", LatestVersion: "2.0.0", IsUpdate: true},
+		},
+		"constraints": {
+			collection: types.Collection{Name: "test.pinned", Version: "1.0.0"},
+			level:      LevelInfo,
+			update:     Update{FromVersion: "1.0.0", ToVersion: "1.0.0", LatestVersion: "2.0.0", IsUpdate: false},
+		},
+	}
+
+	for k, c := range cases {
+		res := checkCollection(c.collection, versions, policy)
+		if res.Level != c.level {
+			t.Errorf("%s: expecting level %s, obtained %s", k, c.level, res.Level)
+		}
+		if !reflect.DeepEqual(res.Metadata, c.update) {
+			t.Errorf("%s: expecting update %+v, obtained %+v", k, c.update, res.Metadata)
+		}
+	}
+}
+
+func TestParseConstraints(t *testing.T) {
+	cases := map[string]bool{
+		"~> 2.3":     true,
+		">=1.2,<2.0": true,
+		"==1.0.0":    true,
+		"*":          true,
+		"latest":     false,
+	}
+	for s, valid := range cases {
+		if _, err := ParseConstraints(s); (err == nil) != valid {
+			t.Errorf("%s: expecting valid %t, obtained error %v", s, valid, err)
+		}
+	}
+}
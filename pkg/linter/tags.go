@@ -0,0 +1,164 @@
+package linter
+
+import (
+	"context"
+	"strings"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/lockfile"
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+// tagProvider is implemented by the RolesProviders resolving the
+// tags of the roles to the commit they point to, such as provider.Git.
+type tagProvider interface {
+	CommitForTag(ctx context.Context, r types.Role, tag string) (string, error)
+}
+
+// MovedTagsLinter checks that the tags the roles hosted on Git repositories
+// are pinned to still point to the commit recorded for them, either by
+// a comment following the version in the requirements file or by a
+// Lockfile. Tags moved to a different commit, as when a release is
+// re-tagged or force-pushed, silently change the roles being installed.
+type MovedTagsLinter struct {
+	// rolesProviders is defined as attribute of the
+	// MovedTagsLinter struct to allow mocking during unit tests
+	rolesProviders map[string]provider.RolesProvider
+
+	// lock, if set, holds the commits
+	// recorded for the roles
+	lock *lockfile.Lockfile
+
+	// parallelism is the number of
+	// roles checked concurrently
+	parallelism int
+
+	// limiter limits the number of concurrent
+	// lookups sent to the Git repositories
+	limiter *Limiter
+}
+
+// NewMovedTagsLinter returns a new MovedTagsLinter
+// resolving the tags on the Git repositories.
+func NewMovedTagsLinter() *MovedTagsLinter {
+	return &MovedTagsLinter{
+		rolesProviders: map[string]provider.RolesProvider{
+			git: provider.NewGit(),
+		},
+		parallelism: DefaultParallelism,
+		limiter:     NewLimiter(DefaultGalaxyConcurrency, DefaultGitConcurrency),
+	}
+}
+
+// WithGitCredentials configures the MovedTagsLinter to use the given
+// credentials to access the Git repositories of each Git server.
+func (m *MovedTagsLinter) WithGitCredentials(credentials ...provider.GitCredentials) {
+	m.rolesProviders[git] = provider.NewGit(credentials...)
+}
+
+// WithLockfile configures the MovedTagsLinter to compare the
+// tags with the commits recorded by the given Lockfile, for
+// the roles without a commit recorded by a comment.
+func (m *MovedTagsLinter) WithLockfile(lock *lockfile.Lockfile) {
+	m.lock = lock
+}
+
+// WithParallelism configures the number of roles
+// checked concurrently by the MovedTagsLinter.
+func (m *MovedTagsLinter) WithParallelism(n int) {
+	m.parallelism = n
+}
+
+// WithLimiter configures the Limiter limiting the number
+// of concurrent lookups sent by the MovedTagsLinter to the
+// Git repositories, instead of the default limits.
+func (m *MovedTagsLinter) WithLimiter(l *Limiter) {
+	m.limiter = l
+}
+
+// Lint checks the tags of the Roles defined in the given Requirements, and in all
+// the Requirements files they include. A Result with LevelError is sent on the
+// output channel, with a TagMovedError, for each role whose tag no longer points
+// to the recorded commit, and for each role whose tag cannot be resolved. Roles
+// without a recorded commit, or pinned to branches or commits, are not checked.
+// Results are sent in the same order the Roles are declared in the Requirements.
+func (m *MovedTagsLinter) Lint(ctx context.Context, requirements *types.Requirements, output chan<- Result) error {
+	// make sure to close the results chan on exit
+	defer close(output)
+
+	var roles, _ = requirementsOf(requirements)
+
+	var results = make([]*Result, len(roles))
+	forEach(ctx, m.parallelism, len(roles), func(i int) {
+		results[i] = m.lintRole(ctx, roles[i])
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	for _, res := range results {
+		if res == nil {
+			continue
+		}
+		res.Linter = "moved-tags"
+		select {
+		case <-ctx.Done():
+			return nil
+		case output <- *res:
+		}
+	}
+	return nil
+}
+
+// lintRole checks the tag of the given Role,
+// returning the Result to be reported, if any.
+func (m *MovedTagsLinter) lintRole(ctx context.Context, role types.Role) *Result {
+	var recorded = m.recordedCommit(role)
+	if len(recorded) == 0 || len(role.Version) == 0 {
+		return nil
+	}
+	if scm, _, err := roleScm(role); err != nil || scm != git {
+		return nil
+	}
+	p, ok := m.rolesProviders[git].(tagProvider)
+	if !ok {
+		return nil
+	}
+
+	release, err := m.limiter.acquire(ctx, git)
+	if err != nil {
+		return nil
+	}
+	commit, err := p.CommitForTag(ctx, role, role.Version)
+	release()
+	switch {
+	case errors.IsRoleVersionNotFoundError(err):
+		// the version is either a branch or a commit, or it
+		// does not exist, as reported by the UpdatesLinter
+		return nil
+	case err != nil:
+		return &Result{Role: role, Level: LevelError, Err: err}
+	case !strings.HasPrefix(commit, recorded):
+		return &Result{Role: role, Level: LevelError, Err: errors.NewTagMovedError(roleName(role), role.Version, recorded, commit)}
+	default:
+		return nil
+	}
+}
+
+// recordedCommit returns the commit recorded for the version of the
+// given Role by the comment following its version or, if none, by the
+// Lockfile, when locked at the same version. Abbreviated commit hashes
+// are returned as recorded.
+func (m *MovedTagsLinter) recordedCommit(role types.Role) string {
+	if len(role.Commit) != 0 {
+		return role.Commit
+	}
+	if m.lock == nil {
+		return ""
+	}
+	if locked, ok := m.lock.FindRole(role); ok && locked.Version == role.Version {
+		return strings.ToLower(locked.Commit)
+	}
+	return ""
+}
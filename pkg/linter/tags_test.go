@@ -0,0 +1,81 @@
+package linter
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/lockfile"
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+// mockTagsProvider resolves the tags in its
+// map, indexed by source@tag, to their commit.
+type mockTagsProvider map[string]string
+
+func (g mockTagsProvider) VersionsForRole(ctx context.Context, r types.Role) ([]string, error) {
+	return nil, errors.NewRoleNotFoundError(r, "mockTagsProvider")
+}
+
+func (g mockTagsProvider) CommitForTag(ctx context.Context, r types.Role, tag string) (string, error) {
+	if commit, ok := g[r.Source+"@"+tag]; ok {
+		return commit, nil
+	}
+	return "", errors.NewRoleVersionNotFoundError(r, nil)
+}
+
+func TestMovedTagsLinter(t *testing.T) {
+	m := &MovedTagsLinter{
+		rolesProviders: map[string]provider.RolesProvider{
+			git: mockTagsProvider{
+				"https://github.com/test/ansible-a@v1.0.0": "5f1e2d3c4b5a",
+				"https://github.com/test/ansible-b@v1.0.0": "e4d3c2b1a0f9",
+				"https://github.com/test/ansible-c@v1.0.0": "0a1b2c3d4e5f",
+				"https://github.com/test/ansible-d@v1.0.0": "abcdef012345",
+			},
+		},
+	}
+	m.WithLockfile(&lockfile.Lockfile{
+		Roles: []lockfile.Role{
+			{Source: "https://github.com/test/ansible-c", Version: "v1.0.0", Commit: "0A1B2C3D4E5F"},
+			{Source: "https://github.com/test/ansible-d", Version: "v1.0.0", Commit: "9999999999"},
+			// locked at a different version
+			{Source: "https://github.com/test/ansible-e", Version: "v0.9.0", Commit: "1111111111"},
+		},
+	})
+
+	requirements := &types.Requirements{
+		Roles: []types.Role{
+			// abbreviated commit recorded by comment
+			{Source: "https://github.com/test/ansible-a", Scm: "git", Version: "v1.0.0", Commit: "5f1e2d3"},
+			// moved tag recorded by comment
+			{Source: "https://github.com/test/ansible-b", Scm: "git", Version: "v1.0.0", Commit: "5f1e2d3"},
+			// commits recorded by the lock file
+			{Source: "https://github.com/test/ansible-c", Scm: "git", Version: "v1.0.0"},
+			{Source: "https://github.com/test/ansible-d", Scm: "git", Version: "v1.0.0"},
+			{Source: "https://github.com/test/ansible-e", Scm: "git", Version: "v1.0.0"},
+			// branches are not checked
+			{Source: "https://github.com/test/ansible-a", Scm: "git", Version: "master", Commit: "5f1e2d3"},
+			// Galaxy roles are not checked
+			{Name: "test.role", Version: "v1.0.0", Commit: "5f1e2d3"},
+		},
+	}
+
+	results := make(chan Result)
+	go m.Lint(context.Background(), requirements, results)
+
+	var obtained []string
+	for res := range results {
+		obtained = append(obtained, fmt.Sprintf("%s %s %v", roleName(res.Role), res.Level, res.Err))
+	}
+	expected := []string{
+		"https://github.com/test/ansible-b ERR tag v1.0.0 of role https://github.com/test/ansible-b has been moved from commit 5f1e2d3 to e4d3c2b1a0f9",
+		"https://github.com/test/ansible-d ERR tag v1.0.0 of role https://github.com/test/ansible-d has been moved from commit 9999999999 to abcdef012345",
+	}
+	if !reflect.DeepEqual(expected, obtained) {
+		t.Errorf("expecting results %v, obtained %v", expected, obtained)
+	}
+}
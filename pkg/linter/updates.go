@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/atosatto/ansible-requirements-lint/pkg/cache"
 	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
 	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
 	"github.com/atosatto/ansible-requirements-lint/pkg/types"
@@ -15,11 +17,89 @@ type Update struct {
 	// FromVersion represents the current version of the role.
 	FromVersion string
 
-	// ToVersion is a more recent version for a role.
+	// ToVersion is the latest version of the role
+	// allowed by the UpdatePolicy.
 	ToVersion string
 
+	// LatestVersion is the latest version of the role
+	// overall, regardless of the version increment and of
+	// the constraints of the UpdatePolicy.
+	LatestVersion string
+
 	// IsUpdate is true if an update has been found for the role.
 	IsUpdate bool
+
+	// StaleAsOf, if not zero, is the time the versions of the role
+	// have been fetched, when they may no longer be up to date
+	// (e.g. when served from the cache in offline mode).
+	StaleAsOf time.Time
+
+	// Server is the name of the Ansible Galaxy server
+	// the versions of the role have been fetched from.
+	Server string
+
+	// IgnoredTags holds the tags of the role which are not
+	// versions, and have been ignored looking for updates.
+	IgnoredTags []string
+}
+
+// Bump is the kind of version increment
+// introduced by an Update.
+type Bump int
+
+const (
+	// BumpNone is used when the Update does
+	// not change the version of the role.
+	BumpNone Bump = iota
+
+	// BumpPatch is used when the Update
+	// only increments the patch version.
+	BumpPatch
+
+	// BumpMinor is used when the Update
+	// increments the minor version.
+	BumpMinor
+
+	// BumpMajor is used when the Update increments
+	// the major version, or when the versions are
+	// not semantic versions and the kind of increment
+	// cannot be determined.
+	BumpMajor
+)
+
+// Bump returns the kind of version increment
+// introduced by the Update.
+func (u Update) Bump() Bump {
+	if !u.IsUpdate || u.FromVersion == u.ToVersion {
+		return BumpNone
+	}
+
+	var prefix = TagPrefix(u.FromVersion)
+	from, err := ParseVersion(u.FromVersion, prefix)
+	if err != nil {
+		return BumpMajor
+	}
+	to, err := ParseVersion(u.ToVersion, prefix)
+	if err != nil {
+		return BumpMajor
+	}
+
+	return versionBump(from, to)
+}
+
+// ParseBump parses the name of a Bump
+// (patch, minor or major).
+func ParseBump(s string) (Bump, error) {
+	switch s {
+	case "patch":
+		return BumpPatch, nil
+	case "minor":
+		return BumpMinor, nil
+	case "major":
+		return BumpMajor, nil
+	default:
+		return BumpNone, fmt.Errorf("unknown version increment %s, allowed values are patch, minor and major", s)
+	}
 }
 
 const (
@@ -29,140 +109,568 @@ const (
 	git = "git"
 
 	// ansibleGalaxy is the value of they used in the rolesProviders
-	// map of the UpdatesLinter to refer to the AnsibleGalaxy implementation
-	// of the RolesProvider interface.
+	// and collectionsProviders maps of the UpdatesLinter to refer
+	// to the AnsibleGalaxy implementation of the RolesProvider
+	// and CollectionsProvider interfaces.
 	ansibleGalaxy = "galaxy"
 )
 
+const (
+	// DefaultParallelism is the default number of roles
+	// and collections checked concurrently by the UpdatesLinter.
+	DefaultParallelism = 8
+
+	// DefaultGalaxyConcurrency is the default maximum number
+	// of concurrent requests sent to Ansible Galaxy.
+	DefaultGalaxyConcurrency = 4
+
+	// DefaultGitConcurrency is the default maximum number
+	// of concurrent requests sent to Git repositories.
+	DefaultGitConcurrency = 4
+)
+
 // UpdatesLinter checks for updates for roles declarations.
 type UpdatesLinter struct {
-	cache map[string]Result
+	// cache, if set, stores the versions
+	// fetched by the providers
+	cache *cache.Cache
+
+	// offline, if true, prevents the branches
+	// of the roles from being listed
+	offline bool
+
+	// dependencies, if set, resolves the transitive
+	// dependencies of the roles to be checked
+	dependencies *DependencyResolver
 
-	// rolesProviders are defined as attribute of the
-	// UpdatesLinter struct to allow mocking during
-	// unit tests
-	rolesProviders map[string]provider.RolesProvider
+	// galaxyURLs are the URLs of the Ansible Galaxy
+	// servers queried by the galaxy providers
+	galaxyURLs []string
+
+	// policy, if set, configures the versions reported
+	// as updates instead of the DefaultUpdatePolicy
+	policy *UpdatePolicy
+
+	// rolesProviders and collectionsProviders are defined
+	// as attribute of the UpdatesLinter struct to allow mocking
+	// during unit tests
+	rolesProviders       map[string]provider.RolesProvider
+	collectionsProviders map[string]provider.CollectionsProvider
+
+	// parallelism is the number of roles
+	// and collections checked concurrently
+	parallelism int
+
+	// limiter limits the number of concurrent
+	// lookups sent to each provider
+	limiter *Limiter
 }
 
 // NewUpdatesLinter returns a new UpdatesLinter.
 func NewUpdatesLinter() *UpdatesLinter {
 	providers := make(map[string]provider.RolesProvider)
 	providers[git] = provider.NewGit()
-	providers[ansibleGalaxy] = provider.NewAnsibleGalaxy(provider.DefaultAnsibleGalaxyURL)
 
-	return &UpdatesLinter{
-		// register the roles providers
-		rolesProviders: providers,
+	u := &UpdatesLinter{
+		// register the roles and collections providers
+		rolesProviders:       providers,
+		collectionsProviders: make(map[string]provider.CollectionsProvider),
+
+		parallelism: DefaultParallelism,
+		limiter:     NewLimiter(DefaultGalaxyConcurrency, DefaultGitConcurrency),
 	}
+	u.WithGalaxyServer(provider.GalaxyServer{URL: provider.DefaultAnsibleGalaxyURL})
+	return u
+}
+
+// WithParallelism configures the number of roles and
+// collections checked concurrently by the UpdatesLinter.
+func (u *UpdatesLinter) WithParallelism(n int) {
+	u.parallelism = n
+}
+
+// WithLimiter configures the Limiter limiting the number
+// of concurrent lookups sent by the UpdatesLinter to each
+// provider, instead of the default limits.
+func (u *UpdatesLinter) WithLimiter(l *Limiter) {
+	u.limiter = l
 }
 
 // WithAnsibleGalaxyURL configures the UpdatesLinter
 // to use the given URL for Ansible Galaxy instead of
 // the default one.
 func (u *UpdatesLinter) WithAnsibleGalaxyURL(url string) {
-	u.rolesProviders[ansibleGalaxy] = provider.NewAnsibleGalaxy(url)
+	u.WithGalaxyServer(provider.GalaxyServer{URL: url})
 }
 
-// Lint checks for updates to the Roles defined in the given Requirements.
+// WithGalaxyServer configures the UpdatesLinter to use
+// the given Ansible Galaxy server, and its credentials,
+// instead of the default one. Collections are looked
+// up through the v3 APIs when exposed by the server.
+func (u *UpdatesLinter) WithGalaxyServer(server provider.GalaxyServer) {
+	u.WithGalaxyServers(server)
+}
+
+// WithGalaxyServers configures the UpdatesLinter to use the
+// given Ansible Galaxy servers, queried in order until one
+// hosting the role or collection is found. The server each
+// version has been fetched from is reported in the Update.
+func (u *UpdatesLinter) WithGalaxyServers(servers ...provider.GalaxyServer) {
+	galaxy := provider.NewGalaxyServers(servers...)
+	u.rolesProviders[ansibleGalaxy] = galaxy
+	u.collectionsProviders[ansibleGalaxy] = galaxy
+
+	u.galaxyURLs = nil
+	for _, s := range servers {
+		u.galaxyURLs = append(u.galaxyURLs, s.URL)
+	}
+}
+
+// WithGitCredentials configures the UpdatesLinter to use the given
+// credentials to access the Git repositories of each Git server.
+func (u *UpdatesLinter) WithGitCredentials(credentials ...provider.GitCredentials) {
+	u.rolesProviders[git] = provider.NewGit(credentials...)
+}
+
+// WithCache configures the UpdatesLinter to look up
+// the versions of roles and collections in the given
+// Cache before querying the providers.
+func (u *UpdatesLinter) WithCache(c *cache.Cache) {
+	u.cache = c
+}
+
+// WithOffline configures the UpdatesLinter not to list the
+// branches of the roles, which are not stored in the cache.
+// Roles pinned to branches are then reported as pinned to
+// versions which cannot be found.
+func (u *UpdatesLinter) WithOffline() {
+	u.offline = true
+}
+
+// WithUpdatePolicy configures the UpdatesLinter to only report
+// the updates allowed by the given UpdatePolicy, instead of the
+// DefaultUpdatePolicy. The latest version overall is reported
+// together with the latest version allowed.
+func (u *UpdatesLinter) WithUpdatePolicy(p UpdatePolicy) {
+	u.policy = &p
+}
+
+// updatePolicy returns the UpdatePolicy
+// configured for the UpdatesLinter.
+func (u *UpdatesLinter) updatePolicy() UpdatePolicy {
+	if u.policy == nil {
+		return DefaultUpdatePolicy
+	}
+	return *u.policy
+}
+
+// WithDependencies configures the UpdatesLinter to also check for
+// updates to the transitive dependencies of the roles, as resolved
+// by the given DependencyResolver. Roles whose dependencies cannot
+// be resolved are reported with a DependenciesNotResolvedError.
+func (u *UpdatesLinter) WithDependencies(d *DependencyResolver) {
+	u.dependencies = d
+}
+
+// Lint checks for updates to the Roles and Collections defined in the given Requirements,
+// and in all the Requirements files they include.
 // Linter Results will be sent on the output channel.
-// In case an update exists for a given Role, the corresponding
+// In case an update exists for a given Role or Collection, the corresponding
 // Result will have the Metadata field set to an Update holding additional information
-// on the new version available for the role.
+// on the new version available for the role or collection.
+// Roles and Collections are checked concurrently, but Results are always sent
+// in the same order the Roles and Collections are declared in the Requirements.
+// When the dependencies are resolved, the Results of the transitive dependencies
+// of each Role follow the one of the Role, and are sent once per dependency.
 func (u *UpdatesLinter) Lint(ctx context.Context, requirements *types.Requirements, output chan<- Result) error {
 	// make sure to close the results chan on exit
 	defer close(output)
-	for _, role := range requirements.Roles {
+
+	// collect the checks to be performed
+	// in the requirements files order
+	var checks []func(context.Context) []Result
+	requirements.Walk(func(r *types.Requirements) error {
+		for _, role := range r.Roles {
+			if len(role.Include) != 0 {
+				// included files are linted while walking the requirements
+				continue
+			}
+			role := role
+			checks = append(checks, func(ctx context.Context) []Result {
+				return u.lintRoleDependencies(ctx, role)
+			})
+		}
+		for _, collection := range r.Collections {
+			collection := collection
+			checks = append(checks, func(ctx context.Context) []Result {
+				return []Result{u.lintCollection(ctx, collection)}
+			})
+		}
+		return nil
+	})
+
+	// run the checks in a pool of workers, signaling
+	// the completion of each of them on the done chans
+	var results = make([][]Result, len(checks))
+	var done = make([]chan struct{}, len(checks))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	go forEach(ctx, u.parallelism, len(checks), func(i int) {
+		results[i] = checks[i](ctx)
+		close(done[i])
+	})
+
+	// send the results in order, reporting the transitive
+	// dependencies pulled in by multiple roles only once
+	var seen = make(map[string]bool)
+	for i := range checks {
 		select {
 		case <-ctx.Done():
 			return nil
-		default:
-			// provider to be used to fetch updates to the role
-			var scm provider.RolesProvider
-
-			// otherwise, we check if the role has any update
-			h := roleHash(role)
-			res, ok := u.cache[h]
-			switch {
-			case ok:
-				// it's cached, so we just have to
-				// send back the result we've found in the cache
-				output <- res
-				continue
-			case strings.HasSuffix(role.Source, ".tar.gz"):
-				fallthrough
-			case strings.HasSuffix(role.Source, ".gz"):
-				fallthrough
-			case strings.HasSuffix(role.Source, ".tar"):
-				fallthrough
-			case strings.HasSuffix(role.Source, ".zip"):
-				// we can't detect updates of tarballs uploaded on a custom webserver
-				output <- Result{
-					Role:  role,
-					Level: LevelInfo,
-					Err:   fmt.Errorf("unable to detect updates for roles distributed via custom webservers"),
-				}
-				continue
-			case role.Scm == "git":
-				scm = u.rolesProviders[git]
-			case role.Scm == "" && strings.HasPrefix(role.Source, "http"):
-				// if it's just an URL, try with the git provider
-				scm = u.rolesProviders[git]
-			case role.Scm == "":
-				scm = u.rolesProviders[ansibleGalaxy]
-			default:
-				output <- Result{
-					Role:  role,
-					Level: LevelError,
-					Err:   errors.NewUnknownScmError(role.Scm),
+		case <-done[i]:
+		}
+		for _, res := range results[i] {
+			if len(res.Collection.Name) == 0 {
+				var key = roleKey(res.Role) + "\x00" + fmt.Sprint(errors.IsDependenciesNotResolvedError(res.Err))
+				if len(res.Role.Via) != 0 && seen[key] {
+					continue
 				}
-				continue
+				seen[key] = true
 			}
-
-			// fetch the versions available for the role
-			versions, err := scm.VersionsForRole(ctx, role)
-			if err != nil {
-				output <- Result{
-					Role:  role,
-					Level: LevelError,
-					Err:   err,
-				}
-				continue
+			res.Linter = "updates"
+			select {
+			case <-ctx.Done():
+				return nil
+			case output <- res:
 			}
+		}
+	}
+	return nil
+}
 
-			// check if the current version of the role is the latest
-			latest := latestVersion(versions)
-			if latest == role.Version {
-				output <- Result{
-					Role:     role,
-					Level:    LevelInfo,
-					Metadata: Update{FromVersion: latest, ToVersion: latest, IsUpdate: false},
-				}
-				continue
-			}
+// rolesProvider returns the RolesProvider registered
+// as p, wrapped by the cache if configured.
+func (u *UpdatesLinter) rolesProvider(p string) provider.RolesProvider {
+	if u.cache == nil {
+		return u.rolesProviders[p]
+	}
+	return u.cache.RolesProvider(u.providerName(p), u.rolesProviders[p])
+}
 
-			// check if there are new versions for the role
-			versionFound := false
-			for _, v := range versions {
-				if v == role.Version {
-					versionFound = true
-					break
-				}
+// collectionsProvider returns the CollectionsProvider
+// registered as p, wrapped by the cache if configured.
+func (u *UpdatesLinter) collectionsProvider(p string) provider.CollectionsProvider {
+	if u.cache == nil {
+		return u.collectionsProviders[p]
+	}
+	return u.cache.CollectionsProvider(u.providerName(p), u.collectionsProviders[p])
+}
+
+// providerName returns the name identifying the provider p in the cache.
+func (u *UpdatesLinter) providerName(p string) string {
+	return cacheName(p, u.galaxyURLs)
+}
+
+// cacheName returns the name identifying the provider p in the cache.
+// The Ansible Galaxy providers are identified by the URLs of their
+// servers, so that the versions fetched from different servers are
+// cached separately.
+func cacheName(p string, galaxyURLs []string) string {
+	if p == ansibleGalaxy {
+		return ansibleGalaxy + "+" + strings.Join(galaxyURLs, ",")
+	}
+	return p
+}
+
+// lintRoleDependencies checks for updates to the given Role and,
+// if the dependencies are resolved, to its transitive dependencies.
+func (u *UpdatesLinter) lintRoleDependencies(ctx context.Context, role types.Role) []Result {
+	if u.dependencies == nil {
+		return []Result{u.lintRole(ctx, role)}
+	}
+
+	var results []Result
+	u.dependencies.Walk(ctx, role, func(r types.Role, err error) {
+		results = append(results, u.lintRole(ctx, r))
+		if err != nil {
+			results = append(results, Result{
+				Role:  r,
+				Level: LevelWarning,
+				Err:   err,
+			})
+		}
+	})
+	return results
+}
+
+// lintRole checks for updates to the given Role.
+func (u *UpdatesLinter) lintRole(ctx context.Context, role types.Role) Result {
+	// provider to be used to fetch updates to the role
+	scm, level, err := roleScm(role)
+	if err != nil {
+		return Result{
+			Role:  role,
+			Level: level,
+			Err:   err,
+		}
+	}
+
+	// fetch the versions available for the role
+	release, err := u.limiter.acquire(ctx, scm)
+	if err != nil {
+		return Result{
+			Role:  role,
+			Level: LevelError,
+			Err:   err,
+		}
+	}
+	lookup, err := provider.LookupRole(ctx, u.rolesProvider(scm), role)
+	release()
+	switch {
+	case errors.IsNotCachedError(err):
+		return Result{
+			Role:  role,
+			Level: LevelWarning,
+			Err:   err,
+		}
+	case err != nil:
+		return Result{
+			Role:  role,
+			Level: LevelError,
+			Err:   err,
+		}
+	}
+
+	res := checkRole(role, lookup.Versions, u.updatePolicy())
+	if errors.IsRoleVersionNotFoundError(res.Err) && scm == git && !u.offline {
+		// roles pinned to branches, rather than to versions
+		// which cannot be found, are reported by the PinningLinter
+		if release, err := u.limiter.acquire(ctx, scm); err == nil {
+			if isBranch(ctx, u.rolesProviders[git], role) {
+				res.Level = LevelInfo
+				res.Err = nil
 			}
-			if !versionFound {
-				output <- Result{
-					Role:     role,
-					Level:    LevelWarning,
-					Err:      errors.NewRoleVersionNotFoundError(role, versions),
-					Metadata: Update{FromVersion: role.Version, ToVersion: latest, IsUpdate: false},
-				}
-			} else {
-				output <- Result{
-					Role:     role,
-					Level:    LevelWarning,
-					Metadata: Update{FromVersion: role.Version, ToVersion: latest, IsUpdate: true},
-				}
+			release()
+		}
+	}
+	return withLookup(res, lookup)
+}
+
+// roleScm returns the key of the provider to be used to fetch the
+// versions of the given Role. If the versions of the Role cannot be
+// fetched, the error is returned together with its level of severity.
+func roleScm(role types.Role) (string, Level, error) {
+	switch {
+	case strings.HasSuffix(role.Source, ".tar.gz"):
+		fallthrough
+	case strings.HasSuffix(role.Source, ".gz"):
+		fallthrough
+	case strings.HasSuffix(role.Source, ".tar"):
+		fallthrough
+	case strings.HasSuffix(role.Source, ".zip"):
+		// we can't detect updates of tarballs uploaded on a custom webserver
+		return "", LevelInfo, fmt.Errorf("unable to detect updates for roles distributed via custom webservers")
+	case role.Scm == "git":
+		return git, "", nil
+	case role.Scm == "" && strings.HasPrefix(role.Source, "http"):
+		// if it's just an URL, try with the git provider
+		return git, "", nil
+	case role.Scm == "" && (strings.HasPrefix(role.Source, "git+") || strings.HasPrefix(role.Source, "git@")):
+		// ansible-galaxy accepts the scm as prefix of the source,
+		// and scp-like URLs can only refer to git repositories
+		return git, "", nil
+	case role.Scm == "":
+		return ansibleGalaxy, "", nil
+	default:
+		return "", LevelError, errors.NewUnknownScmError(role.Scm)
+	}
+}
+
+// checkRole checks whether versions holds
+// any update to the given Role allowed by policy.
+func checkRole(role types.Role, versions []string, policy UpdatePolicy) Result {
+	r := policy.releases(versions, role.Version, role.Name, role.Source)
+	update := Update{
+		FromVersion:   role.Version,
+		ToVersion:     versionTag(r.allowed),
+		LatestVersion: versionTag(r.latest),
+		IgnoredTags:   r.ignored,
+	}
+
+	switch {
+	case r.count == 0:
+		return Result{
+			Role:     role,
+			Level:    LevelWarning,
+			Err:      errors.NewNoReleasesError("role", roleName(role), len(r.ignored)),
+			Metadata: update,
+		}
+	case len(role.Version) == 0:
+		// roles not pinned to any version
+		// are reported by the PinningLinter
+		return Result{
+			Role:     role,
+			Level:    LevelInfo,
+			Metadata: update,
+		}
+	case !containsVersion(versions, role.Version):
+		return Result{
+			Role:     role,
+			Level:    LevelWarning,
+			Err:      errors.NewRoleVersionNotFoundError(role, versions),
+			Metadata: update,
+		}
+	case r.isUpdate(role.Version):
+		update.IsUpdate = true
+		return Result{
+			Role:     role,
+			Level:    LevelWarning,
+			Metadata: update,
+		}
+	default:
+		// the role is at the latest version allowed by the
+		// policy, even if more recent versions may exist
+		update.ToVersion = role.Version
+		return Result{
+			Role:     role,
+			Level:    LevelInfo,
+			Metadata: update,
+		}
+	}
+}
+
+// lintCollection checks for updates to the given Collection.
+func (u *UpdatesLinter) lintCollection(ctx context.Context, collection types.Collection) Result {
+	var lookup provider.Lookup
+	var release func()
+	var err error
+
+	switch collection.Type {
+	case types.CollectionTypeGalaxy, "":
+		if release, err = u.limiter.acquire(ctx, ansibleGalaxy); err == nil {
+			lookup, err = provider.LookupCollection(ctx, u.collectionsProvider(ansibleGalaxy), collection)
+			release()
+		}
+	case types.CollectionTypeGit:
+		// collections hosted on Git repositories are versioned
+		// exactly as roles, so we can rely on the roles provider
+		if release, err = u.limiter.acquire(ctx, git); err == nil {
+			lookup, err = provider.LookupRole(ctx, u.rolesProvider(git), types.Role{
+				Name:    collection.Name,
+				Source:  strings.TrimPrefix(collection.Name, "git+"),
+				Scm:     git,
+				Version: collection.Version,
+			})
+			release()
+		}
+	case types.CollectionTypeURL, types.CollectionTypeFile, types.CollectionTypeDir:
+		// we can't detect updates of tarballs or local directories
+		return Result{
+			Collection: collection,
+			Level:      LevelInfo,
+			Err:        fmt.Errorf("unable to detect updates for collections of type %s", collection.Type),
+		}
+	default:
+		return Result{
+			Collection: collection,
+			Level:      LevelError,
+			Err:        errors.NewUnknownCollectionTypeError(collection.Type),
+		}
+	}
+	switch {
+	case errors.IsNotCachedError(err):
+		return Result{
+			Collection: collection,
+			Level:      LevelWarning,
+			Err:        err,
+		}
+	case err != nil:
+		return Result{
+			Collection: collection,
+			Level:      LevelError,
+			Err:        err,
+		}
+	}
+
+	return withLookup(checkCollection(collection, lookup.Versions, u.updatePolicy()), lookup)
+}
+
+// checkCollection checks whether versions holds
+// any update to the given Collection allowed by policy.
+func checkCollection(collection types.Collection, versions []string, policy UpdatePolicy) Result {
+	r := policy.releases(versions, collection.Version, collection.Name)
+	update := Update{
+		FromVersion:   collection.Version,
+		ToVersion:     versionTag(r.allowed),
+		LatestVersion: versionTag(r.latest),
+		IgnoredTags:   r.ignored,
+	}
+
+	// collections versions can either be pinned to an exact
+	// version or be constrained to a range of versions
+	constraints, isRange := versionConstraints(collection.Version)
+	switch {
+	case r.count == 0:
+		return Result{
+			Collection: collection,
+			Level:      LevelWarning,
+			Err:        errors.NewNoReleasesError("collection", collection.Name, len(r.ignored)),
+			Metadata:   update,
+		}
+	case isRange:
+		// check if the latest allowed version satisfies
+		// the constraints declared for the collection
+		update.IsUpdate = r.allowed != nil && !r.allowed.Check(constraints)
+		if !update.IsUpdate {
+			return Result{
+				Collection: collection,
+				Level:      LevelInfo,
+				Metadata:   update,
 			}
 		}
+		return Result{
+			Collection: collection,
+			Level:      LevelWarning,
+			Metadata:   update,
+		}
+	case !containsVersion(versions, collection.Version):
+		return Result{
+			Collection: collection,
+			Level:      LevelWarning,
+			Err:        errors.NewCollectionVersionNotFoundError(collection, versions),
+			Metadata:   update,
+		}
+	case r.isUpdate(collection.Version):
+		update.IsUpdate = true
+		return Result{
+			Collection: collection,
+			Level:      LevelWarning,
+			Metadata:   update,
+		}
+	default:
+		update.ToVersion = collection.Version
+		return Result{
+			Collection: collection,
+			Level:      LevelInfo,
+			Metadata:   update,
+		}
 	}
-	return nil
+}
+
+// withLookup records in the Update held by res the server
+// the versions have been fetched from, marking the Update
+// as stale if the versions returned by lookup are stale.
+func withLookup(res Result, lookup provider.Lookup) Result {
+	update, ok := res.Metadata.(Update)
+	if !ok {
+		return res
+	}
+	update.Server = lookup.Server
+	if lookup.Stale {
+		update.StaleAsOf = lookup.FetchedAt
+	}
+	res.Metadata = update
+	return res
 }
@@ -2,9 +2,15 @@ package linter
 
 import (
 	"context"
+	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/atosatto/ansible-requirements-lint/pkg/cache"
 	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
 	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
 	"github.com/atosatto/ansible-requirements-lint/pkg/types"
@@ -21,6 +27,10 @@ func (g mockGitProvider) VersionsForRole(ctx context.Context, r types.Role) ([]s
 	}
 }
 
+func (g mockGitProvider) BranchesForRole(ctx context.Context, r types.Role) ([]string, error) {
+	return []string{"master"}, nil
+}
+
 type mockAnsibleGalaxyProvider struct{}
 
 func (g mockAnsibleGalaxyProvider) VersionsForRole(ctx context.Context, r types.Role) ([]string, error) {
@@ -32,6 +42,15 @@ func (g mockAnsibleGalaxyProvider) VersionsForRole(ctx context.Context, r types.
 	}
 }
 
+func (g mockAnsibleGalaxyProvider) VersionsForCollection(ctx context.Context, c types.Collection) ([]string, error) {
+	switch {
+	case c.Name == "test.ansible_requirements_lint":
+		return []string{"1.0.0", "1.1.0"}, nil
+	default:
+		return nil, errors.NewCollectionNotFoundError(c, "mockAnsibleGalaxyProvider")
+	}
+}
+
 func TestUpdatesLinter(t *testing.T) {
 	updatesLinter := &UpdatesLinter{
 		rolesProviders: map[string]provider.RolesProvider{
@@ -53,9 +72,10 @@ func TestUpdatesLinter(t *testing.T) {
 				Version: "v1.0.0",
 			},
 			update: Update{
-				FromVersion: "v1.0.0",
-				ToVersion:   "v1.1.0",
-				IsUpdate:    true,
+				FromVersion:   "v1.0.0",
+				ToVersion:     "v1.1.0",
+				LatestVersion: "v1.1.0",
+				IsUpdate:      true,
 			},
 			level: LevelWarning,
 		},
@@ -65,9 +85,10 @@ func TestUpdatesLinter(t *testing.T) {
 				Version: "v1.1.0",
 			},
 			update: Update{
-				FromVersion: "v1.1.0",
-				ToVersion:   "v1.1.0",
-				IsUpdate:    false,
+				FromVersion:   "v1.1.0",
+				ToVersion:     "v1.1.0",
+				LatestVersion: "v1.1.0",
+				IsUpdate:      false,
 			},
 			level: LevelInfo,
 		},
@@ -76,10 +97,11 @@ func TestUpdatesLinter(t *testing.T) {
 				Source: "test.ansible-requirements-lint",
 			},
 			update: Update{
-				ToVersion: "v1.1.0",
-				IsUpdate:  false,
+				ToVersion:     "v1.1.0",
+				LatestVersion: "v1.1.0",
+				IsUpdate:      false,
 			},
-			level: LevelWarning,
+			level: LevelInfo,
 		},
 		"galaxy:notFound": {
 			role: types.Role{
@@ -96,9 +118,10 @@ func TestUpdatesLinter(t *testing.T) {
 				Version: "v1.0.0",
 			},
 			update: Update{
-				FromVersion: "v1.0.0",
-				ToVersion:   "v1.1.0",
-				IsUpdate:    true,
+				FromVersion:   "v1.0.0",
+				ToVersion:     "v1.1.0",
+				LatestVersion: "v1.1.0",
+				IsUpdate:      true,
 			},
 			level: LevelWarning,
 		},
@@ -108,9 +131,10 @@ func TestUpdatesLinter(t *testing.T) {
 				Version: "v1.1.0",
 			},
 			update: Update{
-				FromVersion: "v1.1.0",
-				ToVersion:   "v1.1.0",
-				IsUpdate:    false,
+				FromVersion:   "v1.1.0",
+				ToVersion:     "v1.1.0",
+				LatestVersion: "v1.1.0",
+				IsUpdate:      false,
 			},
 			level: LevelInfo,
 		},
@@ -136,9 +160,10 @@ func TestUpdatesLinter(t *testing.T) {
 				Version: "v1.0.0",
 			},
 			update: Update{
-				FromVersion: "v1.0.0",
-				ToVersion:   "v1.1.0",
-				IsUpdate:    true,
+				FromVersion:   "v1.0.0",
+				ToVersion:     "v1.1.0",
+				LatestVersion: "v1.1.0",
+				IsUpdate:      true,
 			},
 			level: LevelWarning,
 		},
@@ -149,9 +174,10 @@ func TestUpdatesLinter(t *testing.T) {
 				Version: "v1.0.0",
 			},
 			update: Update{
-				FromVersion: "v1.0.0",
-				ToVersion:   "v1.1.0",
-				IsUpdate:    true,
+				FromVersion:   "v1.0.0",
+				ToVersion:     "v1.1.0",
+				LatestVersion: "v1.1.0",
+				IsUpdate:      true,
 			},
 			level: LevelWarning,
 		},
@@ -162,11 +188,28 @@ func TestUpdatesLinter(t *testing.T) {
 				Version: "master",
 			},
 			update: Update{
-				FromVersion: "master",
-				ToVersion:   "v1.1.0",
-				IsUpdate:    false,
+				FromVersion:   "master",
+				ToVersion:     "v1.1.0",
+				LatestVersion: "v1.1.0",
+				IsUpdate:      false,
+			},
+			// reported by the PinningLinter
+			level: LevelInfo,
+		},
+		"git:versionNotFound": {
+			role: types.Role{
+				Source:  "https://github.com/test/ansible-requirements-lint",
+				Scm:     "git",
+				Version: "v2.0.0",
+			},
+			update: Update{
+				FromVersion:   "v2.0.0",
+				ToVersion:     "v1.1.0",
+				LatestVersion: "v1.1.0",
+				IsUpdate:      false,
 			},
 			level: LevelWarning,
+			err:   &errors.RoleVersionNotFoundError{},
 		},
 		"git:notfound": {
 			role: types.Role{
@@ -210,3 +253,313 @@ func TestUpdatesLinter(t *testing.T) {
 		}
 	}
 }
+
+func TestUpdatesLinterCollections(t *testing.T) {
+	updatesLinter := &UpdatesLinter{
+		rolesProviders: map[string]provider.RolesProvider{
+			git: mockGitProvider{},
+		},
+		collectionsProviders: map[string]provider.CollectionsProvider{
+			ansibleGalaxy: mockAnsibleGalaxyProvider{},
+		},
+	}
+
+	// test cases
+	cases := map[string]struct {
+		collection types.Collection
+		update     Update
+		level      Level
+		err        error
+	}{
+		"galaxy:update": {
+			collection: types.Collection{
+				Name:    "test.ansible_requirements_lint",
+				Version: "1.0.0",
+				Type:    types.CollectionTypeGalaxy,
+			},
+			update: Update{
+				FromVersion:   "1.0.0",
+				ToVersion:     "1.1.0",
+				LatestVersion: "1.1.0",
+				IsUpdate:      true,
+			},
+			level: LevelWarning,
+		},
+		"galaxy:latest": {
+			collection: types.Collection{
+				Name:    "test.ansible_requirements_lint",
+				Version: "1.1.0",
+				Type:    types.CollectionTypeGalaxy,
+			},
+			update: Update{
+				FromVersion:   "1.1.0",
+				ToVersion:     "1.1.0",
+				LatestVersion: "1.1.0",
+				IsUpdate:      false,
+			},
+			level: LevelInfo,
+		},
+		"galaxy:rangeLatest": {
+			collection: types.Collection{
+				Name:    "test.ansible_requirements_lint",
+				Version: ">=1.0.0,<2.0.0",
+				Type:    types.CollectionTypeGalaxy,
+			},
+			update: Update{
+				FromVersion:   ">=1.0.0,<2.0.0",
+				ToVersion:     "1.1.0",
+				LatestVersion: "1.1.0",
+				IsUpdate:      false,
+			},
+			level: LevelInfo,
+		},
+		"galaxy:rangeUpdate": {
+			collection: types.Collection{
+				Name:    "test.ansible_requirements_lint",
+				Version: "==1.0.0",
+				Type:    types.CollectionTypeGalaxy,
+			},
+			update: Update{
+				FromVersion:   "==1.0.0",
+				ToVersion:     "1.1.0",
+				LatestVersion: "1.1.0",
+				IsUpdate:      true,
+			},
+			level: LevelWarning,
+		},
+		"galaxy:anyVersion": {
+			collection: types.Collection{
+				Name:    "test.ansible_requirements_lint",
+				Version: "*",
+				Type:    types.CollectionTypeGalaxy,
+			},
+			update: Update{
+				FromVersion:   "*",
+				ToVersion:     "1.1.0",
+				LatestVersion: "1.1.0",
+				IsUpdate:      false,
+			},
+			level: LevelInfo,
+		},
+		"galaxy:noVersion": {
+			collection: types.Collection{
+				Name: "test.ansible_requirements_lint",
+				Type: types.CollectionTypeGalaxy,
+			},
+			update: Update{
+				ToVersion:     "1.1.0",
+				LatestVersion: "1.1.0",
+				IsUpdate:      false,
+			},
+			level: LevelInfo,
+		},
+		"galaxy:notFound": {
+			collection: types.Collection{
+				Name:    "test.ansible_requirements_lint_notfound",
+				Version: "1.0.0",
+				Type:    types.CollectionTypeGalaxy,
+			},
+			level: LevelError,
+			err:   &errors.CollectionNotFoundError{},
+		},
+		"git:update": {
+			collection: types.Collection{
+				Name:    "git+https://github.com/test/ansible-requirements-lint",
+				Version: "v1.0.0",
+				Type:    types.CollectionTypeGit,
+			},
+			update: Update{
+				FromVersion:   "v1.0.0",
+				ToVersion:     "v1.1.0",
+				LatestVersion: "v1.1.0",
+				IsUpdate:      true,
+			},
+			level: LevelWarning,
+		},
+		"url": {
+			collection: types.Collection{
+				Name: "https://example.com/test-ansible_requirements_lint-1.0.0.tar.gz",
+				Type: types.CollectionTypeURL,
+			},
+			level: LevelInfo,
+		},
+		"unknownType": {
+			collection: types.Collection{
+				Name: "test.ansible_requirements_lint",
+				Type: types.CollectionType("hg"),
+			},
+			level: LevelError,
+			err:   errors.NewUnknownCollectionTypeError("hg"),
+		},
+	}
+
+	for k, c := range cases {
+		results := make(chan Result)
+		requirements := types.Requirements{
+			Collections: []types.Collection{c.collection},
+		}
+		go updatesLinter.Lint(context.Background(), &requirements, results)
+
+		res := <-results
+		if !reflect.DeepEqual(c.collection, res.Collection) {
+			t.Errorf("%s: expecting collection %+v, found %+v", k, c.collection, res.Collection)
+		}
+		if !reflect.DeepEqual(c.update, Update{}) && !reflect.DeepEqual(c.update, res.Metadata) {
+			t.Errorf("%s: expecting update %+v, obtained update %+v", k, c.update, res.Metadata)
+		}
+		if !reflect.DeepEqual(c.level, res.Level) {
+			t.Errorf("%s: expecting level %+v, found %+v", k, c.level, res.Level)
+		}
+		if c.err != nil && reflect.TypeOf(c.err) != reflect.TypeOf(res.Err) {
+			t.Errorf("%s: expecting error of type %T, obtained type %T", k, c.err, res.Err)
+		}
+	}
+}
+
+func TestUpdatesLinterIncludes(t *testing.T) {
+	updatesLinter := &UpdatesLinter{
+		rolesProviders: map[string]provider.RolesProvider{
+			git:           mockGitProvider{},
+			ansibleGalaxy: mockAnsibleGalaxyProvider{},
+		},
+	}
+
+	requirements := types.Requirements{
+		File: "requirements.yml",
+		Roles: []types.Role{
+			{Source: "test.ansible-requirements-lint", Version: "v1.0.0", Position: types.Position{File: "requirements.yml", Line: 1, Column: 3}},
+			{Include: "included.yml", Position: types.Position{File: "requirements.yml", Line: 3, Column: 3}},
+		},
+		Childrens: []*types.Requirements{
+			{
+				File: "included.yml",
+				Roles: []types.Role{
+					{Source: "https://github.com/test/ansible-requirements-lint", Version: "v1.1.0", Position: types.Position{File: "included.yml", Line: 1, Column: 3}},
+				},
+			},
+		},
+	}
+
+	results := make(chan Result)
+	go updatesLinter.Lint(context.Background(), &requirements, results)
+
+	var files []string
+	for res := range results {
+		if res.Level == LevelError {
+			t.Errorf("unexpected error for role %+v: %v", res.Role, res.Err)
+		}
+		files = append(files, res.Position().File)
+	}
+
+	expected := []string{"requirements.yml", "included.yml"}
+	if !reflect.DeepEqual(expected, files) {
+		t.Errorf("expecting results for files %v, obtained %v", expected, files)
+	}
+}
+
+// slowAnsibleGalaxyProvider is a RolesProvider taking
+// longer to answer for the roles declared first, and tracking
+// the maximum number of concurrent lookups.
+type slowAnsibleGalaxyProvider struct {
+	mu         sync.Mutex
+	running    int
+	maxRunning int
+}
+
+func (g *slowAnsibleGalaxyProvider) VersionsForRole(ctx context.Context, r types.Role) ([]string, error) {
+	g.mu.Lock()
+	g.running++
+	if g.running > g.maxRunning {
+		g.maxRunning = g.running
+	}
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		g.running--
+		g.mu.Unlock()
+	}()
+
+	delay, _ := strconv.Atoi(strings.TrimPrefix(r.Name, "test.delay-"))
+	time.Sleep(time.Duration(delay) * time.Millisecond)
+	return []string{"v1.0.0"}, nil
+}
+
+func TestUpdatesLinterConcurrency(t *testing.T) {
+	galaxy := &slowAnsibleGalaxyProvider{}
+	updatesLinter := &UpdatesLinter{
+		rolesProviders: map[string]provider.RolesProvider{
+			ansibleGalaxy: galaxy,
+		},
+		parallelism: 8,
+		limiter:     NewLimiter(3, 0),
+	}
+
+	var requirements types.Requirements
+	var expected []string
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("test.delay-%d", (20-i)*2)
+		requirements.Roles = append(requirements.Roles, types.Role{Name: name, Version: "v1.0.0"})
+		expected = append(expected, name)
+	}
+
+	results := make(chan Result)
+	go updatesLinter.Lint(context.Background(), &requirements, results)
+
+	var names []string
+	for res := range results {
+		names = append(names, res.Role.Name)
+	}
+
+	if !reflect.DeepEqual(expected, names) {
+		t.Errorf("expecting results in order %v, obtained %v", expected, names)
+	}
+	if galaxy.maxRunning > 3 {
+		t.Errorf("expecting at most 3 concurrent Ansible Galaxy lookups, obtained %d", galaxy.maxRunning)
+	}
+	if galaxy.maxRunning < 2 {
+		t.Errorf("expecting roles to be checked concurrently, obtained %d concurrent lookups", galaxy.maxRunning)
+	}
+}
+
+func TestUpdatesLinterOffline(t *testing.T) {
+	var fetchedAt = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c := cache.New("", time.Hour)
+	c.Set(cache.Key{Provider: git, Kind: "role", Source: "https://github.com/test/ansible-requirements-lint"}, []string{"v1.0.0", "v1.1.0"})
+	c.WithOffline()
+
+	updatesLinter := &UpdatesLinter{
+		rolesProviders: map[string]provider.RolesProvider{
+			git: mockGitProvider{},
+		},
+		cache: c,
+	}
+
+	// the entry has just been cached, so it is not stale
+	res := updatesLinter.lintRole(context.Background(), types.Role{Source: "https://github.com/test/ansible-requirements-lint", Scm: "git", Version: "v1.0.0"})
+	if res.Level != LevelWarning || !res.Metadata.(Update).StaleAsOf.IsZero() {
+		t.Errorf("expecting a fresh update, obtained %+v", res)
+	}
+
+	// stale versions are reported with the time they have been fetched
+	res = withLookup(res, provider.Lookup{Versions: []string{"v1.0.0", "v1.1.0"}, FetchedAt: fetchedAt, Stale: true})
+	if update := res.Metadata.(Update); !update.StaleAsOf.Equal(fetchedAt) || !update.IsUpdate {
+		t.Errorf("expecting a stale update as of %v, obtained %+v", fetchedAt, update)
+	}
+
+	// roles missing from the cache are reported as warnings
+	res = updatesLinter.lintRole(context.Background(), types.Role{Source: "https://github.com/test/ansible-requirements-lint-notcached", Scm: "git", Version: "v1.0.0"})
+	if res.Level != LevelWarning || !errors.IsNotCachedError(res.Err) {
+		t.Errorf("expecting a NotCachedError warning, obtained %+v", res)
+	}
+}
+
+func TestUpdatesLinterServer(t *testing.T) {
+	role := types.Role{Name: "test.ansible-requirements-lint", Version: "v1.0.0"}
+
+	res := withLookup(checkRole(role, []string{"v1.0.0", "v1.1.0"}, DefaultUpdatePolicy), provider.Lookup{Server: "private"})
+	if update := res.Metadata.(Update); update.Server != "private" || !update.StaleAsOf.IsZero() || !update.IsUpdate {
+		t.Errorf("expecting a fresh update fetched from the private server, obtained %+v", update)
+	}
+}
@@ -0,0 +1,185 @@
+package linter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	version "github.com/hashicorp/go-version"
+)
+
+// VersionKind is the versioning scheme followed by a tag.
+type VersionKind string
+
+const (
+	// KindSemver is used for tags in the semantic versioning
+	// format, with an optional v prefix (e.g. v1.2.3 or 1.2).
+	KindSemver = VersionKind("semver")
+
+	// KindDate is used for tags holding a date (e.g. 2020.01.15,
+	// 2020-01-15 or 20200115), optionally followed by a build number.
+	KindDate = VersionKind("date")
+)
+
+// dateTag matches the tags holding a
+// date, optionally followed by a build number.
+var dateTag = regexp.MustCompile(`^([0-9]{4})[.-]?([0-9]{2})[.-]?([0-9]{2})(?:[.-]([0-9]+))?$`)
+
+// Version is a tag parsed as a version.
+type Version struct {
+	// Tag is the tag the Version has been parsed from.
+	Tag string
+
+	// Prefix is the prefix of the tag preceding
+	// the version (e.g. myrole- in myrole-1.2.3).
+	Prefix string
+
+	// Kind is the versioning scheme of the tag.
+	Kind VersionKind
+
+	// version is the tag normalized
+	// to be compared to other versions
+	version *version.Version
+}
+
+// ParseVersion parses the given tag as a Version, once the given prefix
+// is removed. Dates are normalized to the year.month.day format, so that
+// they are not mistaken for versions with a pre-release (e.g. 2020-01-15).
+// An error is returned if the tag does not start with the prefix, or if
+// it is not a version.
+func ParseVersion(tag, prefix string) (*Version, error) {
+	if !strings.HasPrefix(tag, prefix) {
+		return nil, fmt.Errorf("tag %s does not start with %s", tag, prefix)
+	}
+	var s = strings.TrimPrefix(tag, prefix)
+
+	if m := dateTag.FindStringSubmatch(s); m != nil && m[2] >= "01" && m[2] <= "12" && m[3] >= "01" && m[3] <= "31" {
+		var normalized = m[1] + "." + m[2] + "." + m[3]
+		if len(m[4]) != 0 {
+			normalized += "." + m[4]
+		}
+		v, err := version.NewVersion(normalized)
+		if err != nil {
+			return nil, fmt.Errorf("tag %s is not a version: %v", tag, err)
+		}
+		return &Version{Tag: tag, Prefix: prefix, Kind: KindDate, version: v}, nil
+	}
+
+	v, err := version.NewVersion(s)
+	if err != nil {
+		return nil, fmt.Errorf("tag %s is not a version", tag)
+	}
+	return &Version{Tag: tag, Prefix: prefix, Kind: KindSemver, version: v}, nil
+}
+
+// TagPrefix returns the prefix preceding the version in the given tag,
+// as used by the repositories hosting multiple roles (e.g. myrole- in
+// myrole-1.2.3). Prefixes start with a letter, and are separated from the
+// version by a character which is not a letter or a digit, or by a v.
+// An empty string is returned if the tag is not a prefixed version.
+func TagPrefix(tag string) string {
+	if _, err := ParseVersion(tag, ""); err == nil {
+		return ""
+	}
+	if len(tag) == 0 || !unicode.IsLetter(rune(tag[0])) {
+		return ""
+	}
+	for i := 1; i < len(tag); i++ {
+		if !unicode.IsDigit(rune(tag[i])) {
+			continue
+		}
+		if prev := rune(tag[i-1]); unicode.IsDigit(prev) || unicode.IsLetter(prev) && prev != 'v' {
+			continue
+		}
+		if _, err := ParseVersion(tag, tag[:i]); err == nil {
+			return tag[:i]
+		}
+	}
+	return ""
+}
+
+// GreaterThan checks whether v is more recent than o.
+func (v *Version) GreaterThan(o *Version) bool {
+	return v.version.GreaterThan(o.version)
+}
+
+// Prerelease returns the pre-release
+// label of the Version, if any.
+func (v *Version) Prerelease() string {
+	return v.version.Prerelease()
+}
+
+// Check checks whether the Version satisfies the given constraints.
+func (v *Version) Check(constraints version.Constraints) bool {
+	return constraints.Check(v.version)
+}
+
+// parseVersions parses the given tags as Versions with the given prefix,
+// returning also the tags starting with the prefix which are not versions.
+// The tags not starting with the prefix are skipped, as they are the tags
+// of other roles hosted on the same repository, while the prefixed tags
+// (e.g. release-2020) are returned as ignored when no prefix is given.
+func parseVersions(tags []string, prefix string) ([]*Version, []string) {
+	var versions []*Version
+	var ignored []string
+	for _, t := range tags {
+		if !strings.HasPrefix(t, prefix) {
+			continue
+		}
+		if len(prefix) == 0 && len(TagPrefix(t)) != 0 {
+			ignored = append(ignored, t)
+			continue
+		}
+		v, err := ParseVersion(t, prefix)
+		if err != nil {
+			ignored = append(ignored, t)
+			continue
+		}
+		versions = append(versions, v)
+	}
+	return versions, ignored
+}
+
+// sameVersion checks whether the tags a and b are the same version,
+// once parsed with their TagPrefix, as for v1.0.0 and 1.0.0. Tags
+// which are not versions are only the same when equal.
+func sameVersion(a, b string) bool {
+	if a == b {
+		return true
+	}
+	va, err := ParseVersion(a, TagPrefix(a))
+	if err != nil {
+		return false
+	}
+	vb, err := ParseVersion(b, TagPrefix(b))
+	if err != nil {
+		return false
+	}
+	return va.Prefix == vb.Prefix && va.Kind == vb.Kind && va.version.Equal(vb.version)
+}
+
+// versionBump returns the kind of version
+// increment introduced going from from to to.
+func versionBump(from, to *Version) Bump {
+	fromSegments, toSegments := from.version.Segments(), to.version.Segments()
+	switch {
+	case from.Kind != to.Kind:
+		return BumpMajor
+	case fromSegments[0] != toSegments[0]:
+		return BumpMajor
+	case fromSegments[1] != toSegments[1]:
+		return BumpMinor
+	default:
+		return BumpPatch
+	}
+}
+
+// versionTag returns the tag the given Version
+// has been parsed from, or an empty string if nil.
+func versionTag(v *Version) string {
+	if v == nil {
+		return ""
+	}
+	return v.Tag
+}
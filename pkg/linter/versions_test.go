@@ -0,0 +1,155 @@
+package linter
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+func TestParseVersion(t *testing.T) {
+	cases := map[string]struct {
+		tag    string
+		prefix string
+		kind   VersionKind
+		err    bool
+	}{
+		"semver":         {tag: "1.2.3", kind: KindSemver},
+		"semver:v":       {tag: "v1.2.3", kind: KindSemver},
+		"semver:partial": {tag: "1.2", kind: KindSemver},
+		"semver:pre":     {tag: "v1.2.3-rc1", kind: KindSemver},
+		"date:dots":      {tag: "2020.01.15", kind: KindDate},
+		"date:dashes":    {tag: "2020-01-15", kind: KindDate},
+		"date:compact":   {tag: "20200115", kind: KindDate},
+		"date:build":     {tag: "2020.01.15.2", kind: KindDate},
+		"prefix":         {tag: "myrole-1.2.3", prefix: "myrole-", kind: KindSemver},
+		"prefix:date":    {tag: "myrole-2020-01-15", prefix: "myrole-", kind: KindDate},
+		"prefix:missing": {tag: "myrole-1.2.3", err: true},
+		"prefix:other":   {tag: "other-1.2.3", prefix: "myrole-", err: true},
+		"branch":         {tag: "stable", err: true},
+		"empty":          {tag: "", err: true},
+	}
+
+	for k, c := range cases {
+		v, err := ParseVersion(c.tag, c.prefix)
+		if (err != nil) != c.err {
+			t.Errorf("%s: expecting error %t, obtained %v", k, c.err, err)
+			continue
+		}
+		if err == nil && (v.Kind != c.kind || v.Tag != c.tag || v.Prefix != c.prefix) {
+			t.Errorf("%s: expecting a %s version, obtained %+v", k, c.kind, v)
+		}
+	}
+
+	// dates are not mistaken for pre-releases
+	older, _ := ParseVersion("2020-01-15", "")
+	newer, _ := ParseVersion("2020-02-01", "")
+	if !newer.GreaterThan(older) || len(older.Prerelease()) != 0 {
+		t.Errorf("expecting 2020-02-01 to be more recent than 2020-01-15")
+	}
+}
+
+func TestTagPrefix(t *testing.T) {
+	cases := map[string]string{
+		"v1.2.3":        "",
+		"2020-01-15":    "",
+		"myrole-1.2.3":  "myrole-",
+		"myrole-v1.2.3": "myrole-v",
+		"myrole2-1.2.3": "myrole2-",
+		"release_2020":  "release_",
+		"stable":        "",
+		">=1.0.0":       "",
+	}
+	for tag, expected := range cases {
+		if prefix := TagPrefix(tag); prefix != expected {
+			t.Errorf("%s: expecting prefix %q, obtained %q", tag, expected, prefix)
+		}
+	}
+}
+
+func TestCheckRoleTags(t *testing.T) {
+	cases := map[string]struct {
+		version  string
+		versions []string
+		policy   UpdatePolicy
+		level    Level
+		update   Update
+		err      error
+	}{
+		"unparseable": {
+			version:  "v1.0.0",
+			versions: []string{"stable", "v1.0.0", "release-candidate", "myrole-2.0.0", "v1.1.0"},
+			level:    LevelWarning,
+			update:   Update{FromVersion: "v1.0.0", ToVersion: "v1.1.0", LatestVersion: "v1.1.0", IsUpdate: true, IgnoredTags: []string{"stable", "release-candidate", "myrole-2.0.0"}},
+		},
+		"prefix": {
+			version:  "myrole-1.0.0",
+			versions: []string{"v3.0.0", "myrole-1.0.0", "myrole-1.2.0", "other-2.0.0"},
+			level:    LevelWarning,
+			update:   Update{FromVersion: "myrole-1.0.0", ToVersion: "myrole-1.2.0", LatestVersion: "myrole-1.2.0", IsUpdate: true},
+		},
+		"prefix:configured": {
+			versions: []string{"v3.0.0", "myrole-1.0.0", "myrole-1.2.0", "other-2.0.0"},
+			policy:   UpdatePolicy{TagPrefixes: map[string]string{"test.role": "myrole-"}},
+			level:    LevelInfo,
+			update:   Update{ToVersion: "myrole-1.2.0", LatestVersion: "myrole-1.2.0"},
+		},
+		"date": {
+			version:  "2020-01-15",
+			versions: []string{"2019-12-01", "2020-01-15", "2020-02-01", "v1.0.0"},
+			level:    LevelWarning,
+			update:   Update{FromVersion: "2020-01-15", ToVersion: "2020-02-01", LatestVersion: "2020-02-01", IsUpdate: true},
+		},
+		"noReleases": {
+			version:  "stable",
+			versions: []string{"stable", "latest"},
+			level:    LevelWarning,
+			update:   Update{FromVersion: "stable", IgnoredTags: []string{"stable", "latest"}},
+			err:      errors.NewNoReleasesError("role", "test.role", 2),
+		},
+		"noReleases:prefix": {
+			versions: []string{"release-2020", "stable"},
+			level:    LevelWarning,
+			update:   Update{IgnoredTags: []string{"release-2020", "stable"}},
+			err:      errors.NewNoReleasesError("role", "test.role", 2),
+		},
+		"noTags": {
+			level: LevelWarning,
+			err:   errors.NewNoReleasesError("role", "test.role", 0),
+		},
+	}
+
+	for k, c := range cases {
+		var policy = c.policy
+		policy.MaxBump = BumpMajor
+		res := checkRole(types.Role{Name: "test.role", Version: c.version}, c.versions, policy)
+		if res.Level != c.level {
+			t.Errorf("%s: expecting level %s, obtained %s", k, c.level, res.Level)
+		}
+		if !reflect.DeepEqual(res.Metadata, c.update) {
+			t.Errorf("%s: expecting update %+v, obtained %+v", k, c.update, res.Metadata)
+		}
+		if !reflect.DeepEqual(res.Err, c.err) {
+			t.Errorf("%s: expecting error %v, obtained %v", k, c.err, res.Err)
+		}
+	}
+}
+
+func TestUpdateBump(t *testing.T) {
+	cases := map[string]struct {
+		update Update
+		bump   Bump
+	}{
+		"patch":        {update: Update{FromVersion: "v1.0.0", ToVersion: "v1.0.1", IsUpdate: true}, bump: BumpPatch},
+		"minor":        {update: Update{FromVersion: "1.0.0", ToVersion: "1.1.0", IsUpdate: true}, bump: BumpMinor},
+		"prefix:minor": {update: Update{FromVersion: "myrole-1.0.0", ToVersion: "myrole-1.1.0", IsUpdate: true}, bump: BumpMinor},
+		"noVersion":    {update: Update{FromVersion: "stable", ToVersion: "v1.1.0", IsUpdate: true}, bump: BumpMajor},
+		"noUpdate":     {update: Update{FromVersion: "v1.0.0", ToVersion: "v1.0.0"}, bump: BumpNone},
+	}
+	for k, c := range cases {
+		if bump := c.update.Bump(); bump != c.bump {
+			t.Errorf("%s: expecting bump %d, obtained %d", k, c.bump, bump)
+		}
+	}
+}
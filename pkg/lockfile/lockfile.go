@@ -0,0 +1,158 @@
+package lockfile
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/provider"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// header is the comment written at the
+// beginning of the lock files.
+const header = "# This file is generated by ansible-requirements-lint lock.\n# Do not edit it manually.\n"
+
+// Lockfile holds the exact versions the roles and collections
+// of a requirements file, and of the files it includes,
+// have been resolved to.
+type Lockfile struct {
+	Roles       []Role       `yaml:"roles,omitempty"`
+	Collections []Collection `yaml:"collections,omitempty"`
+}
+
+// Role is the locked version of a role.
+type Role struct {
+	// Name and Source identify the role
+	// as declared in the requirements file.
+	Name   string `yaml:"name,omitempty"`
+	Source string `yaml:"src,omitempty"`
+	Scm    string `yaml:"scm,omitempty"`
+
+	// Version is the version the role is pinned to
+	// or, if not pinned, the version it has been
+	// resolved to when the lock file was written.
+	Version string `yaml:"version,omitempty"`
+
+	// Commit is the hash of the commit the version
+	// resolves to, for roles hosted on Git repositories.
+	Commit string `yaml:"commit,omitempty"`
+
+	// Checksum is the checksum of the archive of the version,
+	// in the sha256:<hex> format, for roles distributed as archives.
+	Checksum string `yaml:"checksum,omitempty"`
+}
+
+// Collection is the locked version of a collection.
+type Collection struct {
+	// Name, Source and Type identify the collection
+	// as declared in the requirements file.
+	Name   string `yaml:"name"`
+	Source string `yaml:"source,omitempty"`
+	Type   string `yaml:"type,omitempty"`
+
+	// Version is the exact version the
+	// version constraints resolve to.
+	Version string `yaml:"version,omitempty"`
+
+	// Commit is the hash of the commit the version resolves
+	// to, for collections hosted on Git repositories.
+	Commit string `yaml:"commit,omitempty"`
+
+	// Checksum is the checksum of the archive of the version,
+	// in the sha256:<hex> format, for collections distributed
+	// as archives.
+	Checksum string `yaml:"checksum,omitempty"`
+}
+
+// Checksum returns the checksum of an archive, in the
+// format used by the lock file, given its hex encoded
+// SHA-256 checksum.
+func Checksum(sha256 string) string {
+	return "sha256:" + sha256
+}
+
+// PathFor returns the path of the lock file of the given
+// requirements file, which is stored next to it with the
+// .lock extension (e.g. requirements.lock).
+func PathFor(requirementsFile string) string {
+	return strings.TrimSuffix(requirementsFile, filepath.Ext(requirementsFile)) + ".lock"
+}
+
+// Read reads the lock file at path.
+func Read(path string) (*Lockfile, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var l Lockfile
+	if err := yaml.Unmarshal(content, &l); err != nil {
+		return nil, fmt.Errorf("unable to parse the lock file %s: %v", path, err)
+	}
+	return &l, nil
+}
+
+// Write writes the Lockfile to w.
+func (l *Lockfile) Write(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteString(header)
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(l); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// FindRole returns the locked version of the given Role, if any.
+// Roles are matched by their Source or, if not set, by their Name,
+// and by their Version, as the same role may be locked at different
+// versions by different requirements files. If the role is not locked
+// at its Version, its first locked version is returned.
+func (l *Lockfile) FindRole(r types.Role) (Role, bool) {
+	var found Role
+	var ok bool
+	for _, locked := range l.Roles {
+		if identity(locked.Source, locked.Name) != identity(r.Source, r.Name) {
+			continue
+		}
+		if len(r.Version) == 0 || locked.Version == r.Version {
+			return locked, true
+		}
+		if !ok {
+			found, ok = locked, true
+		}
+	}
+	return found, ok
+}
+
+// FindCollection returns the locked version of the given Collection, if any.
+// Collections are matched by their Name and Source.
+func (l *Lockfile) FindCollection(c types.Collection) (Collection, bool) {
+	for _, locked := range l.Collections {
+		if locked.Name == c.Name && identity(locked.Source, "") == identity(c.Source, "") {
+			return locked, true
+		}
+	}
+	return Collection{}, false
+}
+
+// identity returns the normalized source
+// or, if not set, the given name.
+func identity(source, name string) string {
+	if len(source) == 0 {
+		return name
+	}
+	return provider.NormalizeSource(source)
+}
@@ -0,0 +1,79 @@
+package lockfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+func TestLockfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ansible-requirements-lint")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	l := &Lockfile{
+		Roles: []Role{
+			{Name: "test.role", Version: "v1.0.0", Checksum: Checksum("0123456789abcdef")},
+			{Source: "git@github.com:test/ansible-role.git", Scm: "git", Version: "v2.0.0", Commit: "b0e5a5f9f4f1a1c3d6e7f8a9b0c1d2e3f4a5b6c7"},
+			{Name: "test.role", Version: "v1.1.0", Checksum: Checksum("00112233445566778899")},
+		},
+		Collections: []Collection{
+			{Name: "test.collection", Version: "1.1.0", Checksum: Checksum("fedcba9876543210")},
+		},
+	}
+
+	var path = PathFor(filepath.Join(dir, "requirements.yml"))
+	if expected := filepath.Join(dir, "requirements.lock"); path != expected {
+		t.Errorf("expected the lock file at %s, got %s", expected, path)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("unable to create the lock file: %v", err)
+	}
+	if err := l.Write(f); err != nil {
+		t.Fatalf("unable to write the lock file: %v", err)
+	}
+	f.Close()
+
+	content, _ := ioutil.ReadFile(path)
+	if !strings.HasPrefix(string(content), header) || !strings.Contains(string(content), "\nroles:\n- name: test.role\n") {
+		t.Errorf("unexpected content of the lock file:\n%s", content)
+	}
+
+	read, err := Read(path)
+	if err != nil {
+		t.Fatalf("unable to read the lock file: %v", err)
+	}
+	if !reflect.DeepEqual(l, read) {
+		t.Errorf("expected %+v, got %+v", l, read)
+	}
+
+	// roles are matched by their normalized source
+	if r, ok := read.FindRole(types.Role{Source: "git+git@github.com:test/ansible-role", Version: "v2.0.0"}); !ok || r.Commit != l.Roles[1].Commit {
+		t.Errorf("expected the role to be locked, got %+v", r)
+	}
+	// roles locked at different versions are matched by version
+	if r, ok := read.FindRole(types.Role{Name: "test.role", Version: "v1.1.0"}); !ok || r.Checksum != l.Roles[2].Checksum {
+		t.Errorf("expected the role to be locked at v1.1.0, got %+v", r)
+	}
+	if r, ok := read.FindRole(types.Role{Name: "test.role", Version: "v2.0.0"}); !ok || r.Version != "v1.0.0" {
+		t.Errorf("expected the first locked version of the role, got %+v", r)
+	}
+	if _, ok := read.FindRole(types.Role{Name: "test.other"}); ok {
+		t.Errorf("expected the role not to be locked")
+	}
+	if _, ok := read.FindCollection(types.Collection{Name: "test.collection", Version: ">=1.0.0"}); !ok {
+		t.Errorf("expected the collection to be locked")
+	}
+	if _, ok := read.FindCollection(types.Collection{Name: "test.collection", Source: "https://hub.example.com/"}); ok {
+		t.Errorf("expected the collection from another source not to be locked")
+	}
+}
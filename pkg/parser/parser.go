@@ -1,21 +1,96 @@
 package parser
 
 import (
+	"fmt"
 	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
 
 	"github.com/atosatto/ansible-requirements-lint/pkg/types"
 
 	"gopkg.in/yaml.v3"
 )
 
+// MaxIncludeDepth is the maximum number of nested requirements
+// files resolved by the parser, including the outermost one.
+const MaxIncludeDepth = 16
+
+// commitHashPattern matches the full or abbreviated
+// hashes of the commits of Git repositories.
+const commitHashPattern = `[0-9a-fA-F]{7,40}`
+
+var (
+	// commitHash matches the versions which are commit hashes.
+	commitHash = regexp.MustCompile(`^` + commitHashPattern + `$`)
+
+	// commitComment matches the comments recording the
+	// commit the version of a role points to, as in
+	// "# commit: 5f1e2d3" or "# sha=5f1e2d3".
+	commitComment = regexp.MustCompile(`^#\s*(?:commit|sha)\s*[:=]?\s*(` + commitHashPattern + `)\s*$`)
+)
+
 // UnmarshalFromFile parses the Requirements defined in the
 // file stored at the given path.
+// Requirements files included by the file are recursively
+// parsed, relatively to the including file, and added to
+// the Childrens of the returned Requirements.
 func UnmarshalFromFile(path string) (*types.Requirements, error) {
+	return unmarshalFromFile(path, nil)
+}
+
+// unmarshalFromFile parses the Requirements defined in the file stored
+// at path. includedBy is the list of files including path, from the
+// outermost one, and it is used to detect include cycles.
+func unmarshalFromFile(path string, includedBy []string) (*types.Requirements, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range includedBy {
+		if f == absPath {
+			return nil, NewIncludeCycleError(append(append([]string{}, includedBy[i:]...), absPath))
+		}
+	}
+	if len(includedBy) >= MaxIncludeDepth {
+		return nil, NewIncludeDepthError(path)
+	}
+
 	content, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	return Unmarshal(content)
+	requirements, err := Unmarshal(content)
+	if err != nil {
+		return nil, err
+	}
+	requirements.File = path
+	for i := range requirements.Roles {
+		requirements.Roles[i].Position.File = path
+	}
+	for i := range requirements.Collections {
+		requirements.Collections[i].Position.File = path
+	}
+
+	// resolve the included requirements files
+	for _, r := range requirements.Roles {
+		if len(r.Include) == 0 {
+			continue
+		}
+
+		var includePath = r.Include
+		if !filepath.IsAbs(includePath) {
+			includePath = filepath.Join(filepath.Dir(path), includePath)
+		}
+
+		children, err := unmarshalFromFile(includePath, append(append([]string{}, includedBy...), absPath))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		requirements.Childrens = append(requirements.Childrens, children)
+	}
+
+	return requirements, nil
 }
 
 // Unmarshal parses the Ansible Requirements defined in data.
@@ -39,7 +114,7 @@ func Unmarshal(data []byte) (*types.Requirements, error) {
 	switch {
 	case node.Kind == yaml.SequenceNode:
 		// we have a sequence of roles
-		roles, err := parseRolesFromNodesList(childrens)
+		roles, err := parseRolesFromNodesList(data, childrens)
 		if err != nil {
 			return nil, err
 		}
@@ -64,13 +139,17 @@ func Unmarshal(data []byte) (*types.Requirements, error) {
 			case k.Kind == yaml.ScalarNode && k.Value == "dependencies":
 				// we are parsing roles dependencies contained in the meta/main.yml
 				// manifest of an Ansible role
-				roles, err := parseRolesFromNodesList(v.Content)
+				roles, err := parseRolesFromNodesList(data, v.Content)
 				if err != nil {
 					return nil, err
 				}
 				requirements.Roles = roles
 			case k.Kind == yaml.ScalarNode && k.Value == "collections":
-				// collections are not supported yet
+				collections, err := parseCollectionsFromNodesList(data, v.Content)
+				if err != nil {
+					return nil, err
+				}
+				requirements.Collections = collections
 			case k.Kind == yaml.ScalarNode:
 				// when parsing dependencies in the meta/main.yml format
 				// we might encounter here the meta specific fields
@@ -86,11 +165,11 @@ func Unmarshal(data []byte) (*types.Requirements, error) {
 	return &requirements, nil
 }
 
-func parseRolesFromNodesList(nodes []*yaml.Node) ([]types.Role, error) {
+func parseRolesFromNodesList(data []byte, nodes []*yaml.Node) ([]types.Role, error) {
 	var res []types.Role
 
 	for _, n := range nodes {
-		var role = types.Role{}
+		var role = types.Role{Position: nodePosition(n)}
 
 		switch {
 		case n.Kind == yaml.ScalarNode:
@@ -116,6 +195,8 @@ func parseRolesFromNodesList(nodes []*yaml.Node) ([]types.Role, error) {
 					role.Scm = v.Value
 				case k.Kind == yaml.ScalarNode && k.Value == "version":
 					role.Version = v.Value
+					role.Position.Version = scalarSpan(data, v)
+					role.Commit = RecordedCommit(v.LineComment)
 				case k.Kind == yaml.ScalarNode && (k.Value == "name" || k.Value == "role"):
 					role.Name = v.Value
 				case k.Kind == yaml.ScalarNode && k.Value == "include":
@@ -136,3 +217,109 @@ func parseRolesFromNodesList(nodes []*yaml.Node) ([]types.Role, error) {
 
 	return res, nil
 }
+
+func parseCollectionsFromNodesList(data []byte, nodes []*yaml.Node) ([]types.Collection, error) {
+	var res []types.Collection
+
+	for _, n := range nodes {
+		var collection = types.Collection{Position: nodePosition(n)}
+
+		switch {
+		case n.Kind == yaml.ScalarNode:
+			// entries in which only the name of the collection,
+			// or its location, is provided
+			collection.Name = n.Value
+		case n.Kind == yaml.MappingNode:
+			// this is a standard dict-based collection definition
+			var childrens = make([]*yaml.Node, len(n.Content))
+			copy(childrens, n.Content)
+
+			for {
+				if len(childrens) < 2 {
+					break
+				}
+
+				var k, v = childrens[0], childrens[1]
+				childrens = childrens[2:]
+				switch {
+				case k.Kind == yaml.ScalarNode && k.Value == "name":
+					collection.Name = v.Value
+				case k.Kind == yaml.ScalarNode && k.Value == "version":
+					collection.Version = v.Value
+					collection.Position.Version = scalarSpan(data, v)
+				case k.Kind == yaml.ScalarNode && k.Value == "source":
+					collection.Source = v.Value
+				case k.Kind == yaml.ScalarNode && k.Value == "type":
+					collection.Type = types.CollectionType(v.Value)
+				case k.Kind == yaml.ScalarNode:
+					// ignore unknown keys
+				default:
+					return nil, NewUnexpectedNodeKindError(k.Line, k.Kind)
+				}
+			}
+		default:
+			return nil, NewUnexpectedNodeKindError(n.Line, n.Kind)
+		}
+
+		if collection.Type == "" {
+			collection.Type = collectionType(collection.Name)
+		}
+
+		// git collections can be declared with the
+		// <repository>,<version> short-hand syntax
+		if collection.Type == types.CollectionTypeGit {
+			if i := strings.LastIndex(collection.Name, ","); i != -1 {
+				if collection.Version == "" {
+					collection.Version = collection.Name[i+1:]
+				}
+				collection.Name = collection.Name[:i]
+			}
+		}
+
+		res = append(res, collection)
+	}
+
+	return res, nil
+}
+
+// RecordedCommit returns the hash of the commit recorded
+// by the given comment, in lower case, or an empty
+// string if the comment does not record a commit.
+func RecordedCommit(comment string) string {
+	m := commitComment.FindStringSubmatch(strings.TrimSpace(comment))
+	if m == nil {
+		return ""
+	}
+	return strings.ToLower(m[1])
+}
+
+// IsCommitHash checks whether the given version is the full
+// or abbreviated hash of a commit, as recorded in the comments
+// parsed by RecordedCommit.
+func IsCommitHash(version string) bool {
+	return commitHash.MatchString(version)
+}
+
+// collectionType infers the type of a Collection from its name,
+// mimicking the logic implemented by ansible-galaxy when
+// the type of a collection is not explicitly declared.
+func collectionType(name string) types.CollectionType {
+	switch {
+	case strings.HasPrefix(name, "git+"):
+		fallthrough
+	case strings.HasSuffix(strings.SplitN(name, ",", 2)[0], ".git"):
+		return types.CollectionTypeGit
+	case strings.Contains(name, "://"):
+		return types.CollectionTypeURL
+	case strings.HasSuffix(name, ".tar.gz"):
+		return types.CollectionTypeFile
+	case strings.HasPrefix(name, "/"):
+		fallthrough
+	case strings.HasPrefix(name, "."):
+		fallthrough
+	case strings.HasPrefix(name, "~"):
+		return types.CollectionTypeDir
+	default:
+		return types.CollectionTypeGalaxy
+	}
+}
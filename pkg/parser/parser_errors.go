@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -45,3 +46,38 @@ func NewUnexpectedMappingNodeValueError(line int, value string) *UnexpectedMappi
 func (e *UnexpectedMappingNodeValueError) Error() string {
 	return fmt.Sprintf("unexpected yaml dictionary key or value at line %d: %s", e.line, e.value)
 }
+
+// IncludeCycleError is returned when a requirements
+// file includes, directly or indirectly, itself.
+type IncludeCycleError struct {
+	files []string
+}
+
+// NewIncludeCycleError creates a new IncludeCycleError
+func NewIncludeCycleError(files []string) *IncludeCycleError {
+	return &IncludeCycleError{
+		files: files,
+	}
+}
+
+func (e *IncludeCycleError) Error() string {
+	return fmt.Sprintf("include cycle detected: %s", strings.Join(e.files, " -> "))
+}
+
+// IncludeDepthError is returned when
+// the nesting of included requirements files
+// exceeds the MaxIncludeDepth.
+type IncludeDepthError struct {
+	file string
+}
+
+// NewIncludeDepthError creates a new IncludeDepthError
+func NewIncludeDepthError(file string) *IncludeDepthError {
+	return &IncludeDepthError{
+		file: file,
+	}
+}
+
+func (e *IncludeDepthError) Error() string {
+	return fmt.Sprintf("unable to include %s: more than %d levels of nested includes", e.file, MaxIncludeDepth)
+}
@@ -1,6 +1,12 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/atosatto/ansible-requirements-lint/pkg/types"
@@ -23,6 +29,11 @@ func rolesEqual(a, b types.Role) bool {
 	}
 }
 
+func collectionsEqual(a, b types.Collection) bool {
+	a.Position, b.Position = types.Position{}, types.Position{}
+	return a == b
+}
+
 func parseAndCompare(t *testing.T, requirements string, expected types.Requirements) {
 	parsed, err := Unmarshal([]byte(requirements))
 	if err != nil {
@@ -32,6 +43,7 @@ func parseAndCompare(t *testing.T, requirements string, expected types.Requireme
 
 	if len(expected.Roles) != len(parsed.Roles) {
 		t.Errorf("expecting %d roles, parsed %d roles", len(expected.Roles), len(parsed.Roles))
+		return
 	}
 
 	for i, r := range parsed.Roles {
@@ -40,6 +52,18 @@ func parseAndCompare(t *testing.T, requirements string, expected types.Requireme
 			t.Errorf("expecting role %+v, parsed %+v", expR, r)
 		}
 	}
+
+	if len(expected.Collections) != len(parsed.Collections) {
+		t.Errorf("expecting %d collections, parsed %d collections", len(expected.Collections), len(parsed.Collections))
+		return
+	}
+
+	for i, c := range parsed.Collections {
+		expC := expected.Collections[i]
+		if !collectionsEqual(expC, c) {
+			t.Errorf("expecting collection %+v, parsed %+v", expC, c)
+		}
+	}
 }
 
 // TestParseInlineRequirementsFile tests parsing of roles
@@ -119,6 +143,81 @@ func TestParseRolesAndCollectionsRequirementsFile(t *testing.T) {
 	parseAndCompare(t, requirements, expected)
 }
 
+// TestParseCollectionsRequirementsFile tests parsing of collections
+// requirements using both the string and the dictionary based syntax.
+func TestParseCollectionsRequirementsFile(t *testing.T) {
+	var requirements = `
+---
+  roles:
+  - name: test.ansible-requirements-lint-name
+    version: v1.0.0
+
+  collections:
+  - test.ansible_requirements_lint_inline
+
+  - name: test.ansible_requirements_lint_name
+    version: ">=1.0.0,<2.0.0"
+    source: https://galaxy.example.com
+
+  - name: https://github.com/test/ansible-requirements-lint-collection.git
+    type: git
+    version: v1.0.0
+
+  - git+https://github.com/test/ansible-requirements-lint-collection.git,v1.0.0
+
+  - https://example.com/test-ansible_requirements_lint-1.0.0.tar.gz
+
+  - name: ./test-ansible_requirements_lint-1.0.0.tar.gz
+    type: file
+
+  - ./collections/test/ansible_requirements_lint
+`
+	var expected = types.Requirements{
+		Roles: []types.Role{
+			{
+				Name:    "test.ansible-requirements-lint-name",
+				Version: "v1.0.0",
+			},
+		},
+		Collections: []types.Collection{
+			{
+				Name: "test.ansible_requirements_lint_inline",
+				Type: types.CollectionTypeGalaxy,
+			},
+			{
+				Name:    "test.ansible_requirements_lint_name",
+				Version: ">=1.0.0,<2.0.0",
+				Source:  "https://galaxy.example.com",
+				Type:    types.CollectionTypeGalaxy,
+			},
+			{
+				Name:    "https://github.com/test/ansible-requirements-lint-collection.git",
+				Version: "v1.0.0",
+				Type:    types.CollectionTypeGit,
+			},
+			{
+				Name:    "git+https://github.com/test/ansible-requirements-lint-collection.git",
+				Version: "v1.0.0",
+				Type:    types.CollectionTypeGit,
+			},
+			{
+				Name: "https://example.com/test-ansible_requirements_lint-1.0.0.tar.gz",
+				Type: types.CollectionTypeURL,
+			},
+			{
+				Name: "./test-ansible_requirements_lint-1.0.0.tar.gz",
+				Type: types.CollectionTypeFile,
+			},
+			{
+				Name: "./collections/test/ansible_requirements_lint",
+				Type: types.CollectionTypeDir,
+			},
+		},
+	}
+
+	parseAndCompare(t, requirements, expected)
+}
+
 // TestParseMetaRequirementsFile tests parsing of roles
 // requirements from Ansible roles meta definitions.
 func TestParseMetaRequirementsFile(t *testing.T) {
@@ -171,3 +270,183 @@ func TestParseMetaRequirementsFile(t *testing.T) {
 
 	parseAndCompare(t, requirements, expected)
 }
+
+// TestParsePositions tests that the location of roles,
+// collections and their versions are tracked by the parser.
+func TestParsePositions(t *testing.T) {
+	dir := writeRequirementsFiles(t, map[string]string{
+		"requirements.yml": `---
+roles:
+  - name: test.ansible-requirements-lint-name
+    version: v1.0.0
+
+  - src: test.ansible-requirements-lint-src
+    version: "v1.0.0" # pinned
+
+collections:
+  - test.ansible_requirements_lint_inline
+  - name: test.ansible_requirements_lint_name
+    version: '>=1.0.0'
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "requirements.yml")
+	parsed, err := UnmarshalFromFile(path)
+	if err != nil {
+		t.Fatalf("expected no error, obtained %+v", err)
+	}
+
+	var positions []types.Position
+	for _, r := range parsed.Roles {
+		positions = append(positions, r.Position)
+	}
+	for _, c := range parsed.Collections {
+		positions = append(positions, c.Position)
+	}
+
+	expected := []types.Position{
+		{File: path, Line: 3, Column: 5, Version: types.Span{Line: 4, Column: 14, EndLine: 4, EndColumn: 20}},
+		{File: path, Line: 6, Column: 5, Version: types.Span{Line: 7, Column: 14, EndLine: 7, EndColumn: 22}},
+		{File: path, Line: 10, Column: 5},
+		{File: path, Line: 11, Column: 5, Version: types.Span{Line: 12, Column: 14, EndLine: 12, EndColumn: 23}},
+	}
+	if !reflect.DeepEqual(expected, positions) {
+		t.Errorf("expecting positions %+v, parsed %+v", expected, positions)
+	}
+}
+
+func TestParseRecordedCommits(t *testing.T) {
+	parsed, err := Unmarshal([]byte(`---
+- src: https://github.com/test/ansible-requirements-lint-commit
+  version: v1.0.0 # commit: 5F1E2D3C4B5A
+- src: https://github.com/test/ansible-requirements-lint-sha
+  version: v1.0.0 #sha=5f1e2d3
+- src: https://github.com/test/ansible-requirements-lint-comment
+  version: v1.0.0 # pinned to 5f1e2d3
+`))
+	if err != nil {
+		t.Fatalf("expected no error, obtained %+v", err)
+	}
+
+	var commits []string
+	for _, r := range parsed.Roles {
+		commits = append(commits, r.Commit)
+	}
+	expected := []string{"5f1e2d3c4b5a", "5f1e2d3", ""}
+	if !reflect.DeepEqual(expected, commits) {
+		t.Errorf("expecting commits %v, parsed %v", expected, commits)
+	}
+}
+
+// writeRequirementsFiles writes the given requirements files
+// in a temporary directory, returning the path of the directory.
+func writeRequirementsFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "ansible-requirements-lint")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unable to create directory for %s: %v", name, err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unable to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// TestParseIncludedRequirementsFiles tests the recursive
+// parsing of included requirements files.
+func TestParseIncludedRequirementsFiles(t *testing.T) {
+	dir := writeRequirementsFiles(t, map[string]string{
+		"requirements.yml": `
+- name: test.ansible-requirements-lint-root
+  version: v1.0.0
+
+- include: roles/requirements.yml
+`,
+		"roles/requirements.yml": `
+- name: test.ansible-requirements-lint-included
+  version: v1.0.0
+
+- include: nested/requirements.yml
+`,
+		"roles/nested/requirements.yml": `
+- name: test.ansible-requirements-lint-nested
+  version: v1.0.0
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	parsed, err := UnmarshalFromFile(filepath.Join(dir, "requirements.yml"))
+	if err != nil {
+		t.Fatalf("expected no error, obtained %+v", err)
+	}
+
+	var names, files []string
+	parsed.Walk(func(r *types.Requirements) error {
+		rel, _ := filepath.Rel(dir, r.File)
+		files = append(files, rel)
+		for _, role := range r.Roles {
+			if role.Include == "" {
+				names = append(names, role.Name)
+			}
+		}
+		return nil
+	})
+
+	expectedFiles := []string{"requirements.yml", filepath.Join("roles", "requirements.yml"), filepath.Join("roles", "nested", "requirements.yml")}
+	expectedNames := []string{"test.ansible-requirements-lint-root", "test.ansible-requirements-lint-included", "test.ansible-requirements-lint-nested"}
+	if !reflect.DeepEqual(expectedFiles, files) {
+		t.Errorf("expecting files %v, parsed %v", expectedFiles, files)
+	}
+	if !reflect.DeepEqual(expectedNames, names) {
+		t.Errorf("expecting roles %v, parsed %v", expectedNames, names)
+	}
+}
+
+// TestParseIncludeCycle tests that include cycles
+// are detected by the parser.
+func TestParseIncludeCycle(t *testing.T) {
+	dir := writeRequirementsFiles(t, map[string]string{
+		"requirements.yml": `
+- include: other.yml
+`,
+		"other.yml": `
+- include: requirements.yml
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := UnmarshalFromFile(filepath.Join(dir, "requirements.yml"))
+	var cycleErr *IncludeCycleError
+	if !errors.As(err, &cycleErr) {
+		t.Errorf("expecting an IncludeCycleError, obtained %+v", err)
+	}
+}
+
+// TestParseIncludeDepth tests that the parser does not
+// resolve more than MaxIncludeDepth nested requirements files.
+func TestParseIncludeDepth(t *testing.T) {
+	var files = make(map[string]string)
+	for i := 0; i < MaxIncludeDepth; i++ {
+		files[fmt.Sprintf("requirements-%d.yml", i)] = fmt.Sprintf("- include: requirements-%d.yml\n", i+1)
+	}
+	files[fmt.Sprintf("requirements-%d.yml", MaxIncludeDepth)] = "- name: test.ansible-requirements-lint\n"
+	dir := writeRequirementsFiles(t, files)
+	defer os.RemoveAll(dir)
+
+	// requirements-1.yml is the outermost of MaxIncludeDepth files
+	if _, err := UnmarshalFromFile(filepath.Join(dir, "requirements-1.yml")); err != nil {
+		t.Errorf("expecting %d nested files to be resolved, obtained %+v", MaxIncludeDepth, err)
+	}
+
+	_, err := UnmarshalFromFile(filepath.Join(dir, "requirements-0.yml"))
+	var depthErr *IncludeDepthError
+	if !errors.As(err, &depthErr) {
+		t.Errorf("expecting an IncludeDepthError, obtained %+v", err)
+	}
+}
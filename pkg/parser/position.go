@@ -0,0 +1,101 @@
+package parser
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// nodePosition returns the Position of the given yaml.Node.
+func nodePosition(n *yaml.Node) types.Position {
+	return types.Position{
+		Line:   n.Line,
+		Column: n.Column,
+	}
+}
+
+// scalarSpan returns the Span of the scalar node n in data,
+// including the quotes surrounding the value, if any.
+func scalarSpan(data []byte, n *yaml.Node) types.Span {
+	var span = types.Span{
+		Line:      n.Line,
+		Column:    n.Column,
+		EndLine:   n.Line,
+		EndColumn: n.Column,
+	}
+
+	start := Offset(data, n.Line, n.Column)
+	if start < 0 {
+		return span
+	}
+
+	// find the end offset of the scalar
+	var end = -1
+	switch {
+	case n.Style&yaml.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(data); i++ {
+			if data[i] == '\\' {
+				i++
+				continue
+			}
+			if data[i] == '"' {
+				end = i + 1
+				break
+			}
+		}
+	case n.Style&yaml.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(data); i++ {
+			if data[i] != '\'' {
+				continue
+			}
+			if i+1 < len(data) && data[i+1] == '\'' {
+				// escaped single quote
+				i++
+				continue
+			}
+			end = i + 1
+			break
+		}
+	case n.Style&(yaml.LiteralStyle|yaml.FoldedStyle) == 0:
+		// plain scalars spanning a single
+		// line are stored verbatim in data
+		if bytes.HasPrefix(data[start:], []byte(n.Value)) {
+			end = start + len(n.Value)
+		}
+	}
+	if end < 0 {
+		return span
+	}
+
+	// convert the end offset to line and column
+	span.EndLine = n.Line + bytes.Count(data[start:end], []byte("\n"))
+	lineStart := bytes.LastIndexByte(data[:end], '\n') + 1
+	span.EndColumn = utf8.RuneCount(data[lineStart:end]) + 1
+	return span
+}
+
+// Offset returns the byte offset in data of the
+// given 1-based line and column, or -1 if the position
+// is not part of data. Columns are counted in characters,
+// as done by the yaml parser.
+func Offset(data []byte, line, column int) int {
+	var pos = 0
+	for l := 1; l < line; l++ {
+		i := bytes.IndexByte(data[pos:], '\n')
+		if i < 0 {
+			return -1
+		}
+		pos += i + 1
+	}
+	for c := 1; c < column; c++ {
+		if pos >= len(data) || data[pos] == '\n' {
+			return -1
+		}
+		_, size := utf8.DecodeRune(data[pos:])
+		pos += size
+	}
+	return pos
+}
@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+)
+
+const (
+	// maxArchiveSize is the maximum size of the
+	// uncompressed content of the archives of roles.
+	maxArchiveSize = 256 << 20
+
+	// maxMetaFileSize is the maximum size of the
+	// files extracted from the archives of roles.
+	maxMetaFileSize = 1 << 20
+)
+
+// githubArchiveURL is the format of the URL of the archive of
+// a GitHub repository at a given reference, as used by ansible-galaxy
+// to install the roles not advertising a download URL.
+var githubArchiveURL = "https://github.com/%s/%s/archive/%s.tar.gz"
+
+// fetchArchive downloads the gzipped tarball at rawURL, authenticated by
+// auth if not nil, returning the files of its meta directory extracted in
+// memory. As done by ansible-galaxy, the top-level directory of the archive
+// is stripped from the paths of the files.
+func fetchArchive(ctx context.Context, rawURL string, auth *galaxyAuth) (billy.Filesystem, error) {
+	var fs billy.Filesystem
+	err := downloadArchive(ctx, rawURL, auth, func(r io.Reader) error {
+		var err error
+		fs, err = extractArchive(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// archiveChecksum downloads the archive at rawURL, authenticated
+// by auth if not nil, returning its hex encoded SHA-256 checksum.
+func archiveChecksum(ctx context.Context, rawURL string, auth *galaxyAuth) (string, error) {
+	var h = sha256.New()
+	err := downloadArchive(ctx, rawURL, auth, func(r io.Reader) error {
+		_, err := io.Copy(h, r)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// downloadArchive downloads the archive at rawURL, authenticated
+// by auth if not nil, calling read with the content of the archive.
+func downloadArchive(ctx context.Context, rawURL string, auth *galaxyAuth, read func(io.Reader) error) error {
+	client := &http.Client{Timeout: time.Minute}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "ansible-requirements-lint")
+	if err := auth.authorize(ctx, req); err != nil {
+		return err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unable to download %s: unexpected response code %d", rawURL, resp.StatusCode)
+	}
+
+	if err := read(resp.Body); err != nil {
+		return fmt.Errorf("unable to read %s: %v", rawURL, err)
+	}
+	return nil
+}
+
+// extractArchive extracts the regular files of the meta directory of the
+// gzipped tarball read from r in memory, stripping the top-level directory.
+// An error is returned if the uncompressed archive is larger than
+// maxArchiveSize, or if a file is larger than maxMetaFileSize.
+func extractArchive(r io.Reader) (billy.Filesystem, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var fs = memfs.New()
+	var limited = &io.LimitedReader{R: gz, N: maxArchiveSize}
+	var readErr = func(err error) error {
+		if limited.N == 0 {
+			return fmt.Errorf("the archive is larger than %d bytes", maxArchiveSize)
+		}
+		return err
+	}
+
+	var tr = tar.NewReader(limited)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return fs, nil
+		}
+		if err != nil {
+			return nil, readErr(err)
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		// strip the top-level directory
+		var name = path.Clean("/" + hdr.Name)
+		var parts = strings.SplitN(strings.TrimPrefix(name, "/"), "/", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[1], "meta/") {
+			continue
+		}
+		if hdr.Size > maxMetaFileSize {
+			return nil, fmt.Errorf("%s is larger than %d bytes", parts[1], maxMetaFileSize)
+		}
+
+		f, err := fs.Create(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(f, io.LimitReader(tr, maxMetaFileSize))
+		f.Close()
+		if err != nil {
+			return nil, readErr(err)
+		}
+	}
+}
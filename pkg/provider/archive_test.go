@@ -0,0 +1,37 @@
+package provider
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExtractArchive(t *testing.T) {
+	fs, err := extractArchive(bytes.NewReader(tarball(t, map[string]string{
+		"ansible-role/meta/main.yml":         "dependencies: []",
+		"ansible-role/meta/requirements.yml": "[]",
+		"ansible-role/tasks/main.yml":        "[]",
+	})))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// only the files of the meta directory are extracted
+	for _, name := range []string{"meta/main.yml", "meta/requirements.yml"} {
+		if _, err := fs.Stat(name); err != nil {
+			t.Errorf("%s: expected the file to be extracted, got %v", name, err)
+		}
+	}
+	if _, err := fs.Stat("tasks/main.yml"); !os.IsNotExist(err) {
+		t.Errorf("tasks/main.yml: expected the file not to be extracted, got %v", err)
+	}
+
+	// files larger than the limit are an error
+	_, err = extractArchive(bytes.NewReader(tarball(t, map[string]string{
+		"ansible-role/meta/main.yml": strings.Repeat("#", maxMetaFileSize+1),
+	})))
+	if err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("expected an error extracting a large file, got %v", err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
 	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+	"gopkg.in/src-d/go-billy.v4"
 )
 
 const (
@@ -24,6 +25,10 @@ const (
 // for the Ansible Galaxy APIs.
 type AnsibleGalaxy struct {
 	baseURL string
+
+	// auth, if not nil, authenticates
+	// the requests sent to the server
+	auth *galaxyAuth
 }
 
 // NewAnsibleGalaxy creates a new AnsibleGalaxy provider.
@@ -40,98 +45,389 @@ func NewAnsibleGalaxy(baseURL string) AnsibleGalaxy {
 	return g
 }
 
+// NewAnsibleGalaxyServer creates a new AnsibleGalaxy provider
+// for the given server, authenticating the requests sent to
+// the server with its credentials.
+func NewAnsibleGalaxyServer(server GalaxyServer) AnsibleGalaxy {
+	g := NewAnsibleGalaxy(server.URL)
+	g.auth = newGalaxyAuth(server)
+	return g
+}
+
+// galaxyRole is a role returned by the v1 APIs of Ansible Galaxy.
+type galaxyRole struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	GithubUser   string `json:"github_user"`
+	GithubRepo   string `json:"github_repo"`
+	GithubBranch string `json:"github_branch"`
+}
+
+// galaxyRoleVersion is a version of a role
+// returned by the v1 APIs of Ansible Galaxy.
+type galaxyRoleVersion struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"download_url"`
+}
+
 // VersionsForRole returns the list of versions available on AnsibleGalaxy for the Role r.
+// As ansible-galaxy does, the role is looked up by the exact namespace and name
+// in its namespace.name identifier.
 func (g AnsibleGalaxy) VersionsForRole(ctx context.Context, r types.Role) ([]string, error) {
-	client := &http.Client{Timeout: time.Second * 10}
+	role, err := g.role(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	roleVersions, err := g.roleVersions(ctx, r, role)
+	if err != nil {
+		return nil, err
+	}
 
-	// Ansible Galaxy URL
-	baseURL, err := url.Parse(g.baseURL + "/api/v1/search/roles/")
+	var versions []string
+	for _, v := range roleVersions {
+		versions = append(versions, v.Name)
+	}
+	return versions, nil
+}
+
+// FetchRole downloads the archive of the Role r at its Version, as done by
+// ansible-galaxy install, returning the files of its meta directory. The
+// archive is downloaded from the location advertised by Ansible Galaxy for
+// the version or, if none, from the GitHub repository of the role. Roles
+// without a Version are only fetched, at the default branch of their
+// repository, when they have no versions: the version of the others is to
+// be selected by the caller among the ones returned by VersionsForRole.
+func (g AnsibleGalaxy) FetchRole(ctx context.Context, r types.Role) (billy.Filesystem, error) {
+	_, archiveURL, err := g.roleArchive(ctx, r)
 	if err != nil {
 		return nil, err
 	}
+	return fetchArchive(ctx, archiveURL, g.archiveAuth(archiveURL))
+}
 
-	// keywords to be used for the search on Ansible Galaxy
-	var keywords string
-	if len(r.Source) != 0 {
-		keywords = r.Source
-	} else {
-		keywords = r.Name
+// RoleArtifact returns the archive of the Role r at its Version, downloaded
+// from the same location as FetchRole, together with its checksum. As for
+// FetchRole, Roles without a Version are only resolved when they have no
+// versions.
+func (g AnsibleGalaxy) RoleArtifact(ctx context.Context, r types.Role) (Artifact, error) {
+	version, archiveURL, err := g.roleArchive(ctx, r)
+	if err != nil {
+		return Artifact{}, err
+	}
+	checksum, err := archiveChecksum(ctx, archiveURL, g.archiveAuth(archiveURL))
+	if err != nil {
+		return Artifact{}, err
 	}
+	return Artifact{Version: version, URL: archiveURL, Checksum: checksum}, nil
+}
+
+// roleArchive returns the version of the Role r installed by ansible-galaxy,
+// which is its Version or, if not set and the role has no versions, the default
+// branch of its repository, and the URL of its archive. A RoleVersionNotFoundError
+// is returned if the version is not among the versions of the role.
+func (g AnsibleGalaxy) roleArchive(ctx context.Context, r types.Role) (string, string, error) {
+	role, err := g.role(ctx, r)
+	if err != nil {
+		return "", "", err
+	}
+	roleVersions, err := g.roleVersions(ctx, r, role)
+	if err != nil {
+		return "", "", err
+	}
+
+	var names []string
+	for _, v := range roleVersions {
+		names = append(names, v.Name)
+	}
+
+	// select the version to be installed
+	// mimicking the logic of ansible-galaxy
+	var version = r.Version
+	switch {
+	case len(version) != 0:
+	case len(names) != 0:
+		// the version to be installed
+		// is selected by the caller
+		return "", "", errors.NewRoleVersionNotFoundError(r, names)
+	case len(role.GithubBranch) != 0:
+		version = role.GithubBranch
+	default:
+		version = "master"
+	}
+
+	if len(names) != 0 && !containsString(names, version) {
+		return "", "", errors.NewRoleVersionNotFoundError(r, names)
+	}
+
+	for _, v := range roleVersions {
+		if v.Name == version && len(v.DownloadURL) != 0 {
+			// the download URL may be relative to the server
+			archiveURL, err := resolveURL(g.baseURL+"/", v.DownloadURL)
+			return version, archiveURL, err
+		}
+	}
+	if len(role.GithubUser) == 0 || len(role.GithubRepo) == 0 {
+		return "", "", fmt.Errorf("unable to find the archive of role %s on %s", role.Name, g.baseURL)
+	}
+	return version, fmt.Sprintf(githubArchiveURL, url.PathEscape(role.GithubUser), url.PathEscape(role.GithubRepo), url.PathEscape(version)), nil
+}
+
+// archiveAuth returns the galaxyAuth authenticating the download of
+// the archive at archiveURL. The credentials of the server are only
+// sent to the server itself, and not to GitHub or other hosts.
+func (g AnsibleGalaxy) archiveAuth(archiveURL string) *galaxyAuth {
+	archive, err := url.Parse(archiveURL)
+	if err != nil {
+		return nil
+	}
+	server, err := url.Parse(g.baseURL)
+	if err != nil || archive.Host != server.Host {
+		return nil
+	}
+	return g.auth
+}
+
+// role looks up the Role r on Ansible Galaxy
+// by the namespace and name in its identifier.
+func (g AnsibleGalaxy) role(ctx context.Context, r types.Role) (galaxyRole, error) {
+	// identifier of the role on Ansible Galaxy
+	var id = r.Source
+	if len(id) == 0 {
+		id = r.Name
+	}
+
+	// the namespace is everything before the last dot
+	var i = strings.LastIndex(id, ".")
+	if i <= 0 || i == len(id)-1 {
+		return galaxyRole{}, errors.NewInvalidRoleNameError(id)
+	}
+	var namespace, name = id[:i], id[i+1:]
 
-	// set the Ansible Galaxy search parameters
 	params := url.Values{}
-	params.Add("order_by", "-relevance")
+	params.Add("owner__username", namespace)
+	params.Add("name", name)
 
-	// namespace to be used to filter the Ansible Galaxy results
-	var split = strings.Split(keywords, ".")
-	if len(split) > 0 {
-		params.Add("namespaces", split[0])
-		params.Add("keywords", split[1])
-	} else {
-		params.Add("keywords", keywords)
+	var roles struct {
+		Results []galaxyRole `json:"results"`
+	}
+	status, err := getJSON(ctx, g.baseURL+"/api/v1/roles/?"+params.Encode(), g.auth, &roles)
+	if err != nil {
+		return galaxyRole{}, err
+	}
+	if status == http.StatusNotFound || len(roles.Results) == 0 {
+		return galaxyRole{}, errors.NewRoleNotFoundError(r, g.baseURL)
 	}
+	return roles.Results[0], nil
+}
 
-	baseURL.RawQuery = params.Encode()
+// roleVersions returns all the versions of the given role,
+// which is the one found on Ansible Galaxy for the Role r.
+func (g AnsibleGalaxy) roleVersions(ctx context.Context, r types.Role, role galaxyRole) ([]galaxyRoleVersion, error) {
+	type galaxyVersionsPage struct {
+		Next     string              `json:"next"`
+		NextLink string              `json:"next_link"`
+		Results  []galaxyRoleVersion `json:"results"`
+	}
+
+	// follow the pagination of the Ansible Galaxy APIs
+	// until all the versions of the role have been fetched
+	var versions []galaxyRoleVersion
+	var next = fmt.Sprintf("%s/api/v1/roles/%d/versions/?page_size=100", g.baseURL, role.ID)
+	for len(next) != 0 {
+		var page galaxyVersionsPage
+		status, err := getJSON(ctx, next, g.auth, &page)
+		if err != nil {
+			return nil, err
+		}
+		if status == http.StatusNotFound {
+			return nil, errors.NewRoleNotFoundError(r, g.baseURL)
+		}
+
+		versions = append(versions, page.Results...)
+
+		var link = page.NextLink
+		if len(link) == 0 {
+			link = page.Next
+		}
+		if len(link) == 0 {
+			break
+		}
+		// the links returned by the APIs
+		// may be relative to the server
+		if next, err = resolveURL(next, link); err != nil {
+			return nil, err
+		}
+	}
+
+	return versions, nil
+}
+
+// VersionsForCollection returns the list of versions available on AnsibleGalaxy for the Collection c.
+// If the Source of the Collection is set, it will be used as the Ansible Galaxy URL instead of
+// the one configured for the provider.
+func (g AnsibleGalaxy) VersionsForCollection(ctx context.Context, c types.Collection) ([]string, error) {
+	var galaxyURL = g.baseURL
+	if len(c.Source) != 0 {
+		galaxyURL = strings.TrimSuffix(c.Source, "/")
+	}
+
+	var split = strings.Split(c.Name, ".")
+	if len(split) != 2 {
+		return nil, errors.NewCollectionNotFoundError(c, galaxyURL)
+	}
+
+	type galaxyVersionsPage struct {
+		Next    string `json:"next"`
+		Results []struct {
+			Version string `json:"version"`
+		} `json:"results"`
+	}
+
+	// follow the pagination of the Ansible Galaxy APIs
+	// until all the versions of the collection have been fetched
+	var versions []string
+	var next = fmt.Sprintf("%s/api/v2/collections/%s/%s/versions/?page_size=100", galaxyURL, url.PathEscape(split[0]), url.PathEscape(split[1]))
+	for len(next) != 0 {
+		var page galaxyVersionsPage
+		status, err := getJSON(ctx, next, g.auth, &page)
+		if err != nil {
+			return nil, err
+		}
+		if status == http.StatusNotFound {
+			return nil, errors.NewCollectionNotFoundError(c, galaxyURL)
+		}
+
+		for _, v := range page.Results {
+			versions = append(versions, v.Version)
+		}
+		if len(page.Next) == 0 {
+			break
+		}
+		// the links returned by the APIs
+		// may be relative to the server
+		if next, err = resolveURL(next, page.Next); err != nil {
+			return nil, err
+		}
+	}
+
+	return versions, nil
+}
+
+// CollectionArtifact returns the archive of the Collection c at its Version,
+// which must be an exact version, together with the checksum advertised by
+// Ansible Galaxy. If the Source of the Collection is set, it will be used as
+// the Ansible Galaxy URL instead of the one configured for the provider.
+func (g AnsibleGalaxy) CollectionArtifact(ctx context.Context, c types.Collection) (Artifact, error) {
+	var galaxyURL = g.baseURL
+	if len(c.Source) != 0 {
+		galaxyURL = strings.TrimSuffix(c.Source, "/")
+	}
+
+	var split = strings.Split(c.Name, ".")
+	if len(split) != 2 {
+		return Artifact{}, errors.NewCollectionNotFoundError(c, galaxyURL)
+	}
+
+	var versionURL = fmt.Sprintf("%s/api/v2/collections/%s/%s/versions/%s/", galaxyURL, url.PathEscape(split[0]), url.PathEscape(split[1]), url.PathEscape(c.Version))
+	return collectionArtifact(ctx, versionURL, g.auth, c)
+}
+
+// collectionArtifact returns the archive of the Collection c described by the
+// version resource at versionURL, as returned by both the v2 and v3 APIs.
+// If the resource does not advertise the checksum of the archive,
+// the archive is downloaded to compute it.
+func collectionArtifact(ctx context.Context, versionURL string, auth *galaxyAuth, c types.Collection) (Artifact, error) {
+	var version struct {
+		Version     string `json:"version"`
+		DownloadURL string `json:"download_url"`
+		Artifact    struct {
+			SHA256 string `json:"sha256"`
+		} `json:"artifact"`
+	}
+	status, err := getJSON(ctx, versionURL, auth, &version)
+	if err != nil {
+		return Artifact{}, err
+	}
+	if status == http.StatusNotFound {
+		return Artifact{}, errors.NewCollectionVersionNotFoundError(c, nil)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", baseURL.String(), nil)
+	// the download URL may be relative to the server
+	archiveURL, err := resolveURL(versionURL, version.DownloadURL)
 	if err != nil {
-		return nil, err
+		return Artifact{}, err
+	}
+	var checksum = version.Artifact.SHA256
+	if len(checksum) == 0 {
+		if checksum, err = archiveChecksum(ctx, archiveURL, auth); err != nil {
+			return Artifact{}, err
+		}
+	}
+	return Artifact{Version: c.Version, URL: archiveURL, Checksum: checksum}, nil
+}
+
+// getJSON performs a GET request to the Ansible Galaxy APIs, authenticated
+// by auth if not nil, and decodes the JSON response body in v.
+// The HTTP status code of the response is returned to allow the caller
+// to handle not found resources, while any other non successful
+// response is returned as an error.
+func getJSON(ctx context.Context, rawURL string, auth *galaxyAuth, v interface{}) (int, error) {
+	client := &http.Client{Timeout: time.Second * 10}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return 0, err
 	}
 
 	req.Header.Set("User-Agent", "ansible-requirements-lint")
 	req.Header.Add("Accept", "application/json")
+	if err := auth.authorize(ctx, req); err != nil {
+		return 0, err
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 && resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected Ansible Galaxy response code: %d", resp.StatusCode)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return resp.StatusCode, nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return resp.StatusCode, fmt.Errorf("unable to authenticate to Ansible Galaxy (response code %d), check the configured token", resp.StatusCode)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return resp.StatusCode, fmt.Errorf("unexpected Ansible Galaxy response code: %d", resp.StatusCode)
 	}
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return resp.StatusCode, err
 	}
 
-	type galaxyResult struct {
-		Name          string `json:"name"`
-		SummaryFields struct {
-			Versions []struct {
-				Name string `json:"name"`
-			} `json:"versions"`
-			Namespace struct {
-				Name string `json:"name"`
-			} `json:"namespace"`
-		} `json:"summary_fields"`
-	}
+	return resp.StatusCode, json.Unmarshal(body, v)
+}
 
-	var results struct {
-		Count   int            `json:"count"`
-		Results []galaxyResult `json:"results"`
-	}
-	err = json.Unmarshal(body, &results)
-	if err != nil {
-		return nil, err
+// containsString checks whether s is part of the list.
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
 	}
+	return false
+}
 
-	// check if the result returned by the API matches
-	// the role we were looking for
-	switch {
-	case len(results.Results) == 0:
-		fallthrough
-	case params.Get("namespaces") != results.Results[0].SummaryFields.Namespace.Name:
-		fallthrough
-	case params.Get("keywords") != results.Results[0].Name:
-		return nil, errors.NewRoleNotFoundError(r, g.baseURL)
+// resolveURL resolves the reference ref,
+// which may be relative, against base.
+func resolveURL(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
 	}
-
-	// get the latest version of the role
-	versions := make([]string, len(results.Results[0].SummaryFields.Versions))
-	for i, v := range results.Results[0].SummaryFields.Versions {
-		versions[i] = v.Name
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", err
 	}
-	return versions, nil
+	return b.ResolveReference(r).String(), nil
 }
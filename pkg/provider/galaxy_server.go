@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultGalaxyAuthClientID is the client ID used to exchange
+// the offline tokens of Red Hat SSO for access tokens,
+// the same used by ansible-galaxy.
+const DefaultGalaxyAuthClientID = "cloud-services"
+
+// GalaxyServer holds the configuration of an Ansible Galaxy server.
+// Its fields match the keys of the [galaxy_server.<name>] sections of
+// ansible.cfg, so that the same credentials used by ansible-galaxy can
+// be used to query private Automation Hub and Galaxy NG servers.
+type GalaxyServer struct {
+	// Name is the name of the server
+	// in the Ansible configuration.
+	Name string
+
+	// URL is the URL of the server.
+	URL string
+
+	// Token is the API token of the server, or the offline
+	// token used to request access tokens from AuthURL.
+	Token string
+
+	// AuthURL is the URL of the Keycloak server issuing
+	// access tokens, such as Red Hat SSO for Automation Hub.
+	AuthURL string
+
+	// ClientID is the client ID used to request
+	// access tokens from AuthURL.
+	ClientID string
+
+	// Username and Password are used for basic authentication
+	// when no Token is configured.
+	Username string
+	Password string
+}
+
+// galaxyAuth authenticates the requests sent to a GalaxyServer.
+type galaxyAuth struct {
+	server GalaxyServer
+
+	// accessToken is the access token obtained
+	// from the AuthURL of the server
+	mu          sync.Mutex
+	accessToken string
+}
+
+// newGalaxyAuth returns the galaxyAuth for server,
+// or nil if no credentials are configured for it.
+func newGalaxyAuth(server GalaxyServer) *galaxyAuth {
+	if len(server.Token) == 0 && len(server.Username) == 0 {
+		return nil
+	}
+	return &galaxyAuth{server: server}
+}
+
+// authorize sets the Authorization header of req. Servers with an AuthURL
+// are sent the access tokens obtained from it as bearer tokens, while the
+// tokens of the other servers are sent as Galaxy API tokens.
+func (a *galaxyAuth) authorize(ctx context.Context, req *http.Request) error {
+	if a == nil {
+		return nil
+	}
+
+	switch {
+	case len(a.server.Token) != 0 && len(a.server.AuthURL) != 0:
+		token, err := a.bearerToken(ctx)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	case len(a.server.Token) != 0:
+		req.Header.Set("Authorization", "Token "+a.server.Token)
+	default:
+		req.SetBasicAuth(a.server.Username, a.server.Password)
+	}
+	return nil
+}
+
+// bearerToken exchanges the offline token of the server for an access token,
+// which is reused for all the requests sent to the server.
+func (a *galaxyAuth) bearerToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.accessToken) != 0 {
+		return a.accessToken, nil
+	}
+
+	var clientID = a.server.ClientID
+	if len(clientID) == 0 {
+		clientID = DefaultGalaxyAuthClientID
+	}
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("client_id", clientID)
+	form.Set("refresh_token", a.server.Token)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.server.AuthURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "ansible-requirements-lint")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	client := &http.Client{Timeout: time.Second * 10}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("unable to obtain an access token from %s: unexpected response code %d", a.server.AuthURL, resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("unable to obtain an access token from %s: %v", a.server.AuthURL, err)
+	}
+	if len(token.AccessToken) == 0 {
+		return "", fmt.Errorf("unable to obtain an access token from %s: empty access token", a.server.AuthURL)
+	}
+
+	a.accessToken = token.AccessToken
+	return a.accessToken, nil
+}
@@ -0,0 +1,226 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+	"gopkg.in/src-d/go-billy.v4"
+)
+
+// GalaxyServers fetches Ansible Roles and Collections information
+// from an ordered list of Ansible Galaxy servers. As ansible-galaxy
+// does with the server_list of ansible.cfg, the servers are queried
+// in order and the versions are returned from the first server
+// hosting the role or collection.
+type GalaxyServers struct {
+	servers     []GalaxyServer
+	roles       []RolesProvider
+	collections []CollectionsProvider
+}
+
+// NewGalaxyServers creates a new GalaxyServers provider
+// querying the given servers in order. If no server is
+// given, DefaultAnsibleGalaxyURL will be used.
+func NewGalaxyServers(servers ...GalaxyServer) *GalaxyServers {
+	if len(servers) == 0 {
+		servers = []GalaxyServer{{URL: DefaultAnsibleGalaxyURL}}
+	}
+
+	g := &GalaxyServers{servers: servers}
+	for _, s := range servers {
+		g.roles = append(g.roles, NewAnsibleGalaxyServer(s))
+		g.collections = append(g.collections, NewGalaxyNG(s))
+	}
+	return g
+}
+
+// VersionsForRole returns the list of versions available for the Role r
+// on the first server hosting it.
+func (g *GalaxyServers) VersionsForRole(ctx context.Context, r types.Role) ([]string, error) {
+	l, err := g.LookupRole(ctx, r)
+	return l.Versions, err
+}
+
+// LookupRole returns the list of versions available for the Role r
+// on the first server hosting it, together with the server name.
+func (g *GalaxyServers) LookupRole(ctx context.Context, r types.Role) (Lookup, error) {
+	var lookup Lookup
+	err := g.firstRole(ctx, r, func(i int) error {
+		versions, err := g.roles[i].VersionsForRole(ctx, r)
+		lookup = Lookup{Versions: versions, FetchedAt: time.Now(), Server: serverName(g.servers[i])}
+		return err
+	})
+	if err != nil {
+		return Lookup{}, err
+	}
+	return lookup, nil
+}
+
+// FetchRole downloads the content of the Role r
+// from the first server hosting it.
+func (g *GalaxyServers) FetchRole(ctx context.Context, r types.Role) (billy.Filesystem, error) {
+	var fs billy.Filesystem
+	err := g.firstRole(ctx, r, func(i int) error {
+		f, ok := g.roles[i].(RolesFetcher)
+		if !ok {
+			return errors.NewRoleNotFoundError(r, serverName(g.servers[i]))
+		}
+		var err error
+		fs, err = f.FetchRole(ctx, r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// RoleArtifact returns the archive of the Role r
+// from the first server hosting it.
+func (g *GalaxyServers) RoleArtifact(ctx context.Context, r types.Role) (Artifact, error) {
+	var artifact Artifact
+	err := g.firstRole(ctx, r, func(i int) error {
+		p, ok := g.roles[i].(RoleArtifactsProvider)
+		if !ok {
+			return errors.NewRoleNotFoundError(r, serverName(g.servers[i]))
+		}
+		var err error
+		artifact, err = p.RoleArtifact(ctx, r)
+		return err
+	})
+	if err != nil {
+		return Artifact{}, err
+	}
+	return artifact, nil
+}
+
+// firstRole calls fn with the index of each server, in order, until
+// fn succeeds for a server hosting the Role r. If no server hosts the
+// role, the errors other than not found are preferred to be returned.
+func (g *GalaxyServers) firstRole(ctx context.Context, r types.Role, fn func(i int) error) error {
+	var lastErr error
+	for i := range g.roles {
+		err := fn(i)
+		switch {
+		case err == nil:
+			return nil
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.IsInvalidRoleNameError(err):
+			// the name is invalid on any server
+			return err
+		case !errors.IsRoleNotFoundError(err) || lastErr == nil:
+			// report errors other than not found
+			// in case no server hosts the role
+			lastErr = err
+		}
+	}
+	if errors.IsRoleNotFoundError(lastErr) {
+		return errors.NewRoleNotFoundError(r, g.serverNames())
+	}
+	return lastErr
+}
+
+// VersionsForCollection returns the list of versions available for the Collection c
+// on the first server hosting it.
+func (g *GalaxyServers) VersionsForCollection(ctx context.Context, c types.Collection) ([]string, error) {
+	l, err := g.LookupCollection(ctx, c)
+	return l.Versions, err
+}
+
+// LookupCollection returns the list of versions available for the Collection c
+// on the first server hosting it, together with the server name. Collections
+// with a Source are only looked up on the server at the Source URL.
+func (g *GalaxyServers) LookupCollection(ctx context.Context, c types.Collection) (Lookup, error) {
+	var lookup Lookup
+	err := g.firstCollection(ctx, c, func(p CollectionsProvider, server string) error {
+		versions, err := p.VersionsForCollection(ctx, c)
+		lookup = Lookup{Versions: versions, FetchedAt: time.Now(), Server: server}
+		return err
+	})
+	if err != nil {
+		return Lookup{}, err
+	}
+	return lookup, nil
+}
+
+// CollectionArtifact returns the archive of the Collection c
+// from the first server hosting it. Collections with a Source
+// are only looked up on the server at the Source URL.
+func (g *GalaxyServers) CollectionArtifact(ctx context.Context, c types.Collection) (Artifact, error) {
+	var artifact Artifact
+	err := g.firstCollection(ctx, c, func(p CollectionsProvider, server string) error {
+		a, ok := p.(CollectionArtifactsProvider)
+		if !ok {
+			return errors.NewCollectionNotFoundError(c, server)
+		}
+		var err error
+		artifact, err = a.CollectionArtifact(ctx, c)
+		return err
+	})
+	if err != nil {
+		return Artifact{}, err
+	}
+	return artifact, nil
+}
+
+// firstCollection calls fn with the provider and the name of each server,
+// in order, until fn succeeds for a server hosting the Collection c. If no
+// server hosts the collection, the errors other than not found are preferred
+// to be returned. Collections with a Source are only looked up on the server
+// at the Source URL, using the configured server, and its credentials, if
+// the Source is one of the configured servers.
+func (g *GalaxyServers) firstCollection(ctx context.Context, c types.Collection, fn func(p CollectionsProvider, server string) error) error {
+	if len(c.Source) != 0 {
+		var p = g.collections[0]
+		var name = c.Source
+		for i, s := range g.servers {
+			if NormalizeSource(s.URL) == NormalizeSource(c.Source) {
+				p, name = g.collections[i], serverName(s)
+				break
+			}
+		}
+		return fn(p, name)
+	}
+
+	var lastErr error
+	for i, p := range g.collections {
+		err := fn(p, serverName(g.servers[i]))
+		switch {
+		case err == nil:
+			return nil
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case !errors.IsCollectionNotFoundError(err) || lastErr == nil:
+			// report errors other than not found
+			// in case no server hosts the collection
+			lastErr = err
+		}
+	}
+	if errors.IsCollectionNotFoundError(lastErr) {
+		return errors.NewCollectionNotFoundError(c, g.serverNames())
+	}
+	return lastErr
+}
+
+// serverNames returns the comma separated
+// list of the names of the servers.
+func (g *GalaxyServers) serverNames() string {
+	var names = make([]string, len(g.servers))
+	for i, s := range g.servers {
+		names[i] = serverName(s)
+	}
+	return strings.Join(names, ", ")
+}
+
+// serverName returns the name identifying the server s,
+// which is its URL if no name has been configured.
+func serverName(s GalaxyServer) string {
+	if len(s.Name) != 0 {
+		return s.Name
+	}
+	return s.URL
+}
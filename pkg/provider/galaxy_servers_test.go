@@ -0,0 +1,147 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+// staticGalaxy is a RolesProvider and CollectionsProvider
+// serving the versions of a fixed set of roles and collections.
+// The artifacts of the collections have the first of their
+// versions as checksum, while artifacts of roles are not served.
+type staticGalaxy map[string][]string
+
+func (g staticGalaxy) VersionsForRole(ctx context.Context, r types.Role) ([]string, error) {
+	if versions, ok := g[r.Name]; ok {
+		return versions, nil
+	}
+	return nil, errors.NewRoleNotFoundError(r, "staticGalaxy")
+}
+
+func (g staticGalaxy) VersionsForCollection(ctx context.Context, c types.Collection) ([]string, error) {
+	if versions, ok := g[c.Name]; ok {
+		return versions, nil
+	}
+	return nil, errors.NewCollectionNotFoundError(c, "staticGalaxy")
+}
+
+func (g staticGalaxy) CollectionArtifact(ctx context.Context, c types.Collection) (Artifact, error) {
+	if versions, ok := g[c.Name]; ok {
+		return Artifact{Version: c.Version, Checksum: versions[0]}, nil
+	}
+	return Artifact{}, errors.NewCollectionNotFoundError(c, "staticGalaxy")
+}
+
+func TestGalaxyServers(t *testing.T) {
+	hub := staticGalaxy{"redhat.rhel_system_roles": {"1.0.0"}, "test.shared": {"2.0.0"}}
+	galaxy := staticGalaxy{"test.role": {"v1.0.0"}, "test.shared": {"1.0.0"}}
+	g := &GalaxyServers{
+		servers: []GalaxyServer{
+			{Name: "automation_hub", URL: "https://hub.example.com/api/galaxy/"},
+			{URL: "https://galaxy.ansible.com"},
+		},
+		roles:       []RolesProvider{hub, galaxy},
+		collections: []CollectionsProvider{hub, galaxy},
+	}
+
+	cases := map[string]struct {
+		lookup   func() (Lookup, error)
+		versions []string
+		server   string
+	}{
+		"collection on the first server": {
+			lookup: func() (Lookup, error) {
+				return g.LookupCollection(context.Background(), types.Collection{Name: "redhat.rhel_system_roles"})
+			},
+			versions: []string{"1.0.0"},
+			server:   "automation_hub",
+		},
+		"collection on both servers": {
+			lookup: func() (Lookup, error) {
+				return g.LookupCollection(context.Background(), types.Collection{Name: "test.shared"})
+			},
+			versions: []string{"2.0.0"},
+			server:   "automation_hub",
+		},
+		"collection with source": {
+			lookup: func() (Lookup, error) {
+				return g.LookupCollection(context.Background(), types.Collection{Name: "test.shared", Source: "https://galaxy.ansible.com/"})
+			},
+			versions: []string{"1.0.0"},
+			server:   "https://galaxy.ansible.com",
+		},
+		"role on the second server": {
+			lookup:   func() (Lookup, error) { return g.LookupRole(context.Background(), types.Role{Name: "test.role"}) },
+			versions: []string{"v1.0.0"},
+			server:   "https://galaxy.ansible.com",
+		},
+	}
+
+	for name, c := range cases {
+		l, err := c.lookup()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if !reflect.DeepEqual(l.Versions, c.versions) || l.Server != c.server {
+			t.Errorf("%s: expected %v from %s, got %v from %s", name, c.versions, c.server, l.Versions, l.Server)
+		}
+	}
+
+	_, err := g.LookupRole(context.Background(), types.Role{Name: "test.notfound"})
+	if !errors.IsRoleNotFoundError(err) || err.Error() != "unable to find role test.notfound on automation_hub, https://galaxy.ansible.com" {
+		t.Errorf("expected a RoleNotFoundError on all the servers, got %v", err)
+	}
+	// artifacts are fetched from the first server hosting the
+	// role or collection, if supported by the server provider
+	artifact, err := g.CollectionArtifact(context.Background(), types.Collection{Name: "test.shared", Version: "2.0.0"})
+	if err != nil || artifact.Checksum != "2.0.0" {
+		t.Errorf("expected the artifact of the first server, got %+v, %v", artifact, err)
+	}
+	if _, err := g.RoleArtifact(context.Background(), types.Role{Name: "test.role"}); !errors.IsRoleNotFoundError(err) {
+		t.Errorf("expected a RoleNotFoundError, got %v", err)
+	}
+}
+
+func TestGalaxyServersAuth(t *testing.T) {
+	private := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/roles/":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"results": []map[string]interface{}{{"id": 1, "name": "role"}},
+			})
+		case "/api/v1/roles/1/versions/":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"results": []map[string]string{{"name": "v1.0.0"}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer private.Close()
+
+	// roles are looked up with the credentials of each server
+	g := NewGalaxyServers(GalaxyServer{Name: "private", URL: private.URL, Token: "secret"})
+	l, err := g.LookupRole(context.Background(), types.Role{Name: "test.role"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"v1.0.0"}; !reflect.DeepEqual(l.Versions, expected) || l.Server != "private" {
+		t.Errorf("expected %v from private, got %v from %s", expected, l.Versions, l.Server)
+	}
+
+	g = NewGalaxyServers(GalaxyServer{Name: "private", URL: private.URL})
+	if _, err := g.LookupRole(context.Background(), types.Role{Name: "test.role"}); err == nil {
+		t.Errorf("expected an authentication error")
+	}
+}
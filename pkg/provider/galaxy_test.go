@@ -0,0 +1,221 @@
+package provider
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+// newGalaxyFixture starts a server exposing the v1 APIs of Ansible Galaxy,
+// hosting the test.role role, with three versions served over two pages,
+// and the test.role-other role, which would match a search for test.role.
+// The archive of v2.0.0 is served at its download URL, while the ones of
+// the other versions are served at the GitHub repository of the role.
+func newGalaxyFixture(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/roles/", func(w http.ResponseWriter, r *http.Request) {
+		var results = []map[string]interface{}{}
+		for _, role := range []struct {
+			id          int
+			owner, name string
+		}{
+			{id: 1, owner: "test", name: "role-other"},
+			{id: 2, owner: "test", name: "role"},
+		} {
+			if r.URL.Query().Get("owner__username") == role.owner && r.URL.Query().Get("name") == role.name {
+				results = append(results, map[string]interface{}{"id": role.id, "name": role.name, "github_user": role.owner, "github_repo": "ansible-" + role.name})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"count": len(results), "results": results})
+	})
+	mux.HandleFunc("/api/v1/roles/2/versions/", func(w http.ResponseWriter, r *http.Request) {
+		var page = map[string]interface{}{
+			"results": []map[string]string{{"name": "v1.0.0"}, {"name": "v1.1.0"}},
+			"next":    "/api/v1/roles/2/versions/?page=2&page_size=100",
+		}
+		if r.URL.Query().Get("page") == "2" {
+			page = map[string]interface{}{
+				"results": []map[string]string{{"name": "v2.0.0", "download_url": "/download/role-v2.0.0.tar.gz"}},
+				"next":    nil,
+			}
+		}
+		json.NewEncoder(w).Encode(page)
+	})
+	archive := func(version string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write(tarball(t, map[string]string{
+				"ansible-role-" + version + "/meta/main.yml": version,
+			}))
+		}
+	}
+	mux.HandleFunc("/download/role-v2.0.0.tar.gz", archive("v2.0.0"))
+	mux.HandleFunc("/test/ansible-role/archive/v1.0.0.tar.gz", archive("v1.0.0"))
+	return httptest.NewServer(mux)
+}
+
+// tarball returns a gzipped tarball holding the given files.
+func tarball(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("unable to write the tarball: %v", err)
+		}
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func TestAnsibleGalaxyVersionsForRole(t *testing.T) {
+	server := newGalaxyFixture(t)
+	defer server.Close()
+
+	g := NewAnsibleGalaxy(server.URL)
+
+	for _, role := range []types.Role{
+		{Name: "test.role"},
+		{Name: "role", Source: "test.role"},
+	} {
+		versions, err := g.VersionsForRole(context.Background(), role)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if expected := []string{"v1.0.0", "v1.1.0", "v2.0.0"}; !reflect.DeepEqual(versions, expected) {
+			t.Errorf("expected %v, got %v", expected, versions)
+		}
+	}
+
+	if _, err := g.VersionsForRole(context.Background(), types.Role{Name: "test.notfound"}); !errors.IsRoleNotFoundError(err) {
+		t.Errorf("expected a RoleNotFoundError, got %v", err)
+	}
+
+	for _, name := range []string{"role", ".role", "test."} {
+		if _, err := g.VersionsForRole(context.Background(), types.Role{Name: name}); !errors.IsInvalidRoleNameError(err) {
+			t.Errorf("%s: expected an InvalidRoleNameError, got %v", name, err)
+		}
+	}
+}
+
+func TestAnsibleGalaxyVersionsForCollection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/collections/test/collection/versions/" {
+			http.NotFound(w, r)
+			return
+		}
+		// the next pages are linked relatively to the server
+		var page = map[string]interface{}{
+			"results": []map[string]string{{"version": "1.0.0"}},
+			"next":    "/api/v2/collections/test/collection/versions/?page=2&page_size=100",
+		}
+		if r.URL.Query().Get("page") == "2" {
+			page = map[string]interface{}{
+				"results": []map[string]string{{"version": "1.1.0"}},
+				"next":    nil,
+			}
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	g := NewAnsibleGalaxy(server.URL)
+	versions, err := g.VersionsForCollection(context.Background(), types.Collection{Name: "test.collection"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"1.0.0", "1.1.0"}; !reflect.DeepEqual(versions, expected) {
+		t.Errorf("expected %v, got %v", expected, versions)
+	}
+
+	if _, err := g.VersionsForCollection(context.Background(), types.Collection{Name: "test.notfound"}); !errors.IsCollectionNotFoundError(err) {
+		t.Errorf("expected a CollectionNotFoundError, got %v", err)
+	}
+}
+
+func TestAnsibleGalaxyFetchRole(t *testing.T) {
+	server := newGalaxyFixture(t)
+	defer server.Close()
+
+	defer func(u string) { githubArchiveURL = u }(githubArchiveURL)
+	githubArchiveURL = server.URL + "/%s/%s/archive/%s.tar.gz"
+
+	g := NewAnsibleGalaxy(server.URL)
+
+	for version, expected := range map[string]string{"v1.0.0": "v1.0.0", "v2.0.0": "v2.0.0"} {
+		fs, err := g.FetchRole(context.Background(), types.Role{Name: "test.role", Version: version})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", version, err)
+			continue
+		}
+		f, err := fs.Open("meta/main.yml")
+		if err != nil {
+			t.Errorf("%s: unable to open meta/main.yml: %v", version, err)
+			continue
+		}
+		content, _ := ioutil.ReadAll(f)
+		f.Close()
+		if string(content) != expected {
+			t.Errorf("%s: expected content %q, got %q", version, expected, content)
+		}
+	}
+
+	// the version of roles having versions is to be selected by the caller
+	for _, version := range []string{"v3.0.0", ""} {
+		if _, err := g.FetchRole(context.Background(), types.Role{Name: "test.role", Version: version}); !errors.IsRoleVersionNotFoundError(err) {
+			t.Errorf("%s: expected a RoleVersionNotFoundError, got %v", version, err)
+		}
+	}
+
+	// archives which cannot be downloaded are reported
+	if _, err := g.FetchRole(context.Background(), types.Role{Name: "test.role", Version: "v1.1.0"}); err == nil || !strings.Contains(err.Error(), fmt.Sprint(http.StatusNotFound)) {
+		t.Errorf("expected a download error, got %v", err)
+	}
+}
+
+func TestAnsibleGalaxyRoleArtifact(t *testing.T) {
+	server := newGalaxyFixture(t)
+	defer server.Close()
+
+	defer func(u string) { githubArchiveURL = u }(githubArchiveURL)
+	githubArchiveURL = server.URL + "/%s/%s/archive/%s.tar.gz"
+
+	g := NewAnsibleGalaxy(server.URL)
+
+	for version, expected := range map[string]Artifact{
+		"v1.0.0": {Version: "v1.0.0", URL: server.URL + "/test/ansible-role/archive/v1.0.0.tar.gz"},
+		"v2.0.0": {Version: "v2.0.0", URL: server.URL + "/download/role-v2.0.0.tar.gz"},
+	} {
+		artifact, err := g.RoleArtifact(context.Background(), types.Role{Name: "test.role", Version: version})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", version, err)
+			continue
+		}
+		if artifact.Version != expected.Version || artifact.URL != expected.URL {
+			t.Errorf("%s: expected %+v, got %+v", version, expected, artifact)
+		}
+		if len(artifact.Checksum) != 64 {
+			t.Errorf("%s: expected a SHA-256 checksum, got %q", version, artifact.Checksum)
+		}
+	}
+
+	for _, version := range []string{"v3.0.0", ""} {
+		if _, err := g.RoleArtifact(context.Background(), types.Role{Name: "test.role", Version: version}); !errors.IsRoleVersionNotFoundError(err) {
+			t.Errorf("%s: expected a RoleVersionNotFoundError, got %v", version, err)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+// GalaxyNG fetches Ansible Collections information from the
+// v3 APIs of Galaxy NG and Red Hat Automation Hub servers.
+// As ansible-galaxy does, the APIs exposed by the server are
+// discovered on the first request, and servers not exposing
+// the v3 APIs are queried using the v2 APIs instead.
+type GalaxyNG struct {
+	server GalaxyServer
+	auth   *galaxyAuth
+
+	// mu protects the APIs discovered on the server,
+	// and the providers of the collections hosted
+	// on other servers
+	mu      sync.Mutex
+	v3URL   string
+	v2      *AnsibleGalaxy
+	sources map[string]*GalaxyNG
+}
+
+// NewGalaxyNG creates a new GalaxyNG provider for the given server.
+// If the URL of the server is a nil string, DefaultAnsibleGalaxyURL
+// will be used instead.
+func NewGalaxyNG(server GalaxyServer) *GalaxyNG {
+	if len(server.URL) == 0 {
+		server.URL = DefaultAnsibleGalaxyURL
+	}
+	return &GalaxyNG{
+		server:  server,
+		auth:    newGalaxyAuth(server),
+		sources: make(map[string]*GalaxyNG),
+	}
+}
+
+// VersionsForCollection returns the list of versions available on the server for the Collection c.
+// If the Source of the Collection is set, it will be used as the server URL instead of the one
+// configured for the provider.
+func (g *GalaxyNG) VersionsForCollection(ctx context.Context, c types.Collection) ([]string, error) {
+	if len(c.Source) != 0 && NormalizeSource(c.Source) != NormalizeSource(g.server.URL) {
+		return g.source(c.Source).VersionsForCollection(ctx, types.Collection{Name: c.Name, Version: c.Version, Type: c.Type})
+	}
+
+	v3URL, v2, err := g.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if v2 != nil {
+		return v2.VersionsForCollection(ctx, c)
+	}
+
+	var split = strings.Split(c.Name, ".")
+	if len(split) != 2 {
+		return nil, errors.NewCollectionNotFoundError(c, g.server.URL)
+	}
+
+	// the v3 APIs are paginated either with the links
+	// of the JSON:API specification or, on older
+	// servers, as the v2 APIs
+	type galaxyVersionsPage struct {
+		Links struct {
+			Next string `json:"next"`
+		} `json:"links"`
+		Next string `json:"next"`
+		Data []struct {
+			Version string `json:"version"`
+		} `json:"data"`
+		Results []struct {
+			Version string `json:"version"`
+		} `json:"results"`
+	}
+
+	// follow the pagination of the APIs until all
+	// the versions of the collection have been fetched
+	var versions []string
+	var next = fmt.Sprintf("%scollections/%s/%s/versions/?limit=100", v3URL, url.PathEscape(split[0]), url.PathEscape(split[1]))
+	for len(next) != 0 {
+		var page galaxyVersionsPage
+		status, err := getJSON(ctx, next, g.auth, &page)
+		if err != nil {
+			return nil, err
+		}
+		if status == http.StatusNotFound {
+			return nil, errors.NewCollectionNotFoundError(c, g.server.URL)
+		}
+
+		for _, v := range page.Data {
+			versions = append(versions, v.Version)
+		}
+		for _, v := range page.Results {
+			versions = append(versions, v.Version)
+		}
+
+		var link = page.Links.Next
+		if len(link) == 0 {
+			link = page.Next
+		}
+		if len(link) == 0 {
+			break
+		}
+		// the links returned by the APIs
+		// may be relative to the server
+		if next, err = resolveURL(next, link); err != nil {
+			return nil, err
+		}
+	}
+
+	return versions, nil
+}
+
+// CollectionArtifact returns the archive of the Collection c at its Version,
+// which must be an exact version, together with the checksum advertised by
+// the server. If the Source of the Collection is set, it will be used as the
+// server URL instead of the one configured for the provider.
+func (g *GalaxyNG) CollectionArtifact(ctx context.Context, c types.Collection) (Artifact, error) {
+	if len(c.Source) != 0 && NormalizeSource(c.Source) != NormalizeSource(g.server.URL) {
+		return g.source(c.Source).CollectionArtifact(ctx, types.Collection{Name: c.Name, Version: c.Version, Type: c.Type})
+	}
+
+	v3URL, v2, err := g.discover(ctx)
+	if err != nil {
+		return Artifact{}, err
+	}
+	if v2 != nil {
+		return v2.CollectionArtifact(ctx, c)
+	}
+
+	var split = strings.Split(c.Name, ".")
+	if len(split) != 2 {
+		return Artifact{}, errors.NewCollectionNotFoundError(c, g.server.URL)
+	}
+
+	var versionURL = fmt.Sprintf("%scollections/%s/%s/versions/%s/", v3URL, url.PathEscape(split[0]), url.PathEscape(split[1]), url.PathEscape(c.Version))
+	return collectionArtifact(ctx, versionURL, g.auth, c)
+}
+
+// discover discovers the APIs exposed by the server, returning
+// the URL of the v3 APIs, or the provider for the v2 APIs if the
+// server does not expose the v3 ones.
+func (g *GalaxyNG) discover(ctx context.Context) (string, *AnsibleGalaxy, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.v3URL) != 0 || g.v2 != nil {
+		return g.v3URL, g.v2, nil
+	}
+
+	var serverURL = strings.TrimSuffix(g.server.URL, "/") + "/"
+
+	// the server URL may either point to the
+	// root of the APIs, or to the server root
+	var api struct {
+		AvailableVersions map[string]string `json:"available_versions"`
+	}
+	var apiURL string
+	for _, u := range []string{serverURL, serverURL + "api/"} {
+		status, err := getJSON(ctx, u, g.auth, &api)
+		if err != nil && status != http.StatusOK {
+			return "", nil, fmt.Errorf("unable to discover the APIs of %s: %v", g.server.URL, err)
+		}
+		if len(api.AvailableVersions) != 0 {
+			apiURL = u
+			break
+		}
+	}
+
+	v3, ok := api.AvailableVersions["v3"]
+	if !ok {
+		// fallback to the v2 APIs exposed by the server,
+		// authenticated with the same credentials
+		var base = strings.TrimSuffix(strings.TrimSuffix(apiURL, "/"), "/api")
+		if len(base) == 0 {
+			base = strings.TrimSuffix(serverURL, "/")
+		}
+		v2 := NewAnsibleGalaxy(base)
+		v2.auth = g.auth
+		g.v2 = &v2
+		return "", g.v2, nil
+	}
+
+	v3URL, err := resolveURL(apiURL, v3)
+	if err != nil {
+		return "", nil, err
+	}
+	g.v3URL = strings.TrimSuffix(v3URL, "/") + "/"
+	return g.v3URL, nil, nil
+}
+
+// source returns the GalaxyNG provider for the
+// collections hosted on the server at rawURL.
+func (g *GalaxyNG) source(rawURL string) *GalaxyNG {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, ok := g.sources[rawURL]
+	if !ok {
+		p = NewGalaxyNG(GalaxyServer{URL: rawURL})
+		g.sources[rawURL] = p
+	}
+	return p
+}
@@ -0,0 +1,193 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+// newGalaxyNGFixture starts a server exposing the v3 APIs under
+// /api/galaxy/, serving three versions of the test.collection
+// collection over two pages. The checksum of the archive is only
+// advertised for version 2.0.0, while the archive of version 1.0.0
+// is served at its download URL. Requests not holding the given
+// Authorization header are rejected.
+func newGalaxyNGFixture(t *testing.T, authorization string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/galaxy/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/galaxy/" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"available_versions": map[string]string{"v3": "v3/"},
+		})
+	})
+	mux.HandleFunc("/api/galaxy/v3/collections/test/collection/versions/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != authorization {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/galaxy/v3/collections/test/collection/versions/":
+		case "/api/galaxy/v3/collections/test/collection/versions/1.0.0/":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"version":      "1.0.0",
+				"download_url": "/download/test-collection-1.0.0.tar.gz",
+			})
+			return
+		case "/api/galaxy/v3/collections/test/collection/versions/2.0.0/":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"version":      "2.0.0",
+				"download_url": "/download/test-collection-2.0.0.tar.gz",
+				"artifact":     map[string]string{"sha256": "0123456789abcdef"},
+			})
+			return
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		var page = map[string]interface{}{
+			"data":  []map[string]string{{"version": "1.0.0"}, {"version": "1.1.0"}},
+			"links": map[string]interface{}{"next": "/api/galaxy/v3/collections/test/collection/versions/?limit=100&offset=2"},
+		}
+		if r.URL.Query().Get("offset") == "2" {
+			page = map[string]interface{}{
+				"data":  []map[string]string{{"version": "2.0.0"}},
+				"links": map[string]interface{}{"next": nil},
+			}
+		}
+		json.NewEncoder(w).Encode(page)
+	})
+	mux.HandleFunc("/download/test-collection-1.0.0.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != authorization {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("1.0.0"))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestGalaxyNGVersionsForCollection(t *testing.T) {
+	server := newGalaxyNGFixture(t, "Token secret")
+	defer server.Close()
+
+	for _, u := range []string{server.URL + "/api/galaxy/", server.URL + "/api/galaxy"} {
+		g := NewGalaxyNG(GalaxyServer{URL: u, Token: "secret"})
+
+		versions, err := g.VersionsForCollection(context.Background(), types.Collection{Name: "test.collection"})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", u, err)
+		}
+		if expected := []string{"1.0.0", "1.1.0", "2.0.0"}; !reflect.DeepEqual(versions, expected) {
+			t.Errorf("%s: expected %v, got %v", u, expected, versions)
+		}
+
+		_, err = g.VersionsForCollection(context.Background(), types.Collection{Name: "test.notfound"})
+		if !errors.IsCollectionNotFoundError(err) {
+			t.Errorf("%s: expected a CollectionNotFoundError, got %v", u, err)
+		}
+	}
+
+	// requests without the token are rejected
+	g := NewGalaxyNG(GalaxyServer{URL: server.URL + "/api/galaxy/"})
+	if _, err := g.VersionsForCollection(context.Background(), types.Collection{Name: "test.collection"}); err == nil {
+		t.Errorf("expected an authentication error")
+	}
+}
+
+func TestGalaxyNGCollectionArtifact(t *testing.T) {
+	server := newGalaxyNGFixture(t, "Token secret")
+	defer server.Close()
+
+	g := NewGalaxyNG(GalaxyServer{URL: server.URL + "/api/galaxy/", Token: "secret"})
+
+	// the archive is downloaded when its checksum is not advertised
+	for version, expected := range map[string]Artifact{
+		"1.0.0": {Version: "1.0.0", URL: server.URL + "/download/test-collection-1.0.0.tar.gz", Checksum: fmt.Sprintf("%x", sha256.Sum256([]byte("1.0.0")))},
+		"2.0.0": {Version: "2.0.0", URL: server.URL + "/download/test-collection-2.0.0.tar.gz", Checksum: "0123456789abcdef"},
+	} {
+		artifact, err := g.CollectionArtifact(context.Background(), types.Collection{Name: "test.collection", Version: version})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", version, err)
+			continue
+		}
+		if artifact != expected {
+			t.Errorf("%s: expected %+v, got %+v", version, expected, artifact)
+		}
+	}
+
+	if _, err := g.CollectionArtifact(context.Background(), types.Collection{Name: "test.collection", Version: "3.0.0"}); !errors.IsCollectionVersionNotFoundError(err) {
+		t.Errorf("expected a CollectionVersionNotFoundError, got %v", err)
+	}
+}
+
+func TestGalaxyNGAuthURL(t *testing.T) {
+	server := newGalaxyNGFixture(t, "Bearer access")
+	defer server.Close()
+
+	var exchanges int
+	sso := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "offline" || r.Form.Get("client_id") != DefaultGalaxyAuthClientID {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		exchanges++
+		fmt.Fprint(w, `{"access_token": "access"}`)
+	}))
+	defer sso.Close()
+
+	g := NewGalaxyNG(GalaxyServer{URL: server.URL + "/api/galaxy/", Token: "offline", AuthURL: sso.URL})
+	for i := 0; i < 2; i++ {
+		if _, err := g.VersionsForCollection(context.Background(), types.Collection{Name: "test.collection"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if exchanges != 1 {
+		t.Errorf("expected the offline token to be exchanged once, got %d exchanges", exchanges)
+	}
+}
+
+func TestGalaxyNGFallbackV2(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Token secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"available_versions": map[string]string{"v1": "v1/", "v2": "v2/"},
+			})
+		case "/api/v2/collections/test/collection/versions/":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"results": []map[string]string{{"version": "1.0.0"}, {"version": "1.1.0"}},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// the credentials are used by the v2 APIs as well
+	g := NewGalaxyNG(GalaxyServer{URL: server.URL, Token: "secret"})
+	versions, err := g.VersionsForCollection(context.Background(), types.Collection{Name: "test.collection"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"1.0.0", "1.1.0"}; !reflect.DeepEqual(versions, expected) {
+		t.Errorf("expected %v, got %v", expected, versions)
+	}
+}
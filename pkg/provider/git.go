@@ -3,55 +3,326 @@ package provider
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
 	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+	"gopkg.in/src-d/go-billy.v4"
 	"gopkg.in/src-d/go-billy.v4/memfs"
 	gogit "gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/protocol/packp"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport/client"
 	"gopkg.in/src-d/go-git.v4/storage/memory"
 )
 
 // Git fetches Ansible Roles information
 // from remote Git repositories.
-type Git struct{}
+type Git struct {
+	credentials []GitCredentials
+}
 
-// NewGit creates a new Git provider.
-func NewGit() Git {
-	return Git{}
+// NewGit creates a new Git provider, using the given
+// credentials to access the repositories of each Git server.
+func NewGit(credentials ...GitCredentials) Git {
+	return Git{credentials: credentials}
 }
 
 // VersionsForRole returns the list of versions available on the upstream Git repository for Role r.
+// Versions are the tags of the repository, which are listed from the references advertised
+// by the remote, as done by git ls-remote, without fetching any object.
 func (g Git) VersionsForRole(ctx context.Context, r types.Role) ([]string, error) {
-	// Use an in-memory filesystem for the repo and git objects
-	fs := memfs.New()
-	storer := memory.NewStorage()
+	refs, err := g.advertisedReferences(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return tags(refs), nil
+}
 
-	// Clone the repository
-	repo, err := gogit.CloneContext(ctx, storer, fs, &gogit.CloneOptions{URL: r.Source})
+// BranchesForRole returns the list of branches of the upstream Git repository
+// for Role r, which are listed from the references advertised by the remote.
+func (g Git) BranchesForRole(ctx context.Context, r types.Role) ([]string, error) {
+	refs, err := g.advertisedReferences(ctx, r)
 	if err != nil {
-		return nil, fmt.Errorf("cloning %s: %v", r.Source, err)
+		return nil, err
+	}
+
+	var branches []string
+	for name := range refs.References {
+		ref := plumbing.ReferenceName(name)
+		if ref.IsBranch() {
+			branches = append(branches, ref.Short())
+		}
+	}
+	sort.Strings(branches)
+	return branches, nil
+}
+
+// CommitForVersion returns the hash of the commit the given version of
+// Role r resolves to. The version can either be a tag, a branch or a commit hash.
+// Tags and branches are resolved from the references advertised by the remote,
+// while the repository is cloned only when the version is not an advertised
+// reference and the commit metadata are required to resolve it.
+// An empty version resolves to the HEAD of the repository, which is the
+// commit ansible-galaxy installs for roles without a version.
+func (g Git) CommitForVersion(ctx context.Context, r types.Role, version string) (string, error) {
+	refs, err := g.advertisedReferences(ctx, r)
+	if err != nil {
+		return "", err
+	}
+
+	if len(version) == 0 {
+		if refs.Head == nil {
+			return "", fmt.Errorf("unable to resolve the HEAD of %s: the repository is empty", r.Source)
+		}
+		return refs.Head.String(), nil
+	}
+
+	for _, name := range []plumbing.ReferenceName{
+		plumbing.NewTagReferenceName(version),
+		plumbing.NewBranchReferenceName(version),
+	} {
+		if hash, ok := advertisedCommit(refs, name); ok {
+			return hash.String(), nil
+		}
+	}
+
+	// the version is not an advertised reference,
+	// so we fall back to clone the repository to look
+	// for a commit matching the version
+	repo, err := g.clone(ctx, r, memfs.New(), &gogit.CloneOptions{NoCheckout: true})
+	if err != nil {
+		return "", err
+	}
+	hash, err := resolveCommit(repo, version)
+	if err != nil {
+		return "", err
+	}
+	if hash.IsZero() {
+		r.Version = version
+		return "", errors.NewRoleVersionNotFoundError(r, tags(refs))
+	}
+	return hash.String(), nil
+}
+
+// CommitForTag returns the hash of the commit the given tag of Role r
+// currently points to, as advertised by the remote. Annotated tags are
+// resolved to the commit they point to rather than to the tag object.
+// A RoleVersionNotFoundError is returned if tag is not a tag of the
+// repository, as when it is a branch or a commit hash.
+func (g Git) CommitForTag(ctx context.Context, r types.Role, tag string) (string, error) {
+	refs, err := g.advertisedReferences(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	if hash, ok := advertisedCommit(refs, plumbing.NewTagReferenceName(tag)); ok {
+		return hash.String(), nil
+	}
+	r.Version = tag
+	return "", errors.NewRoleVersionNotFoundError(r, tags(refs))
+}
+
+// advertisedCommit returns the hash of the commit the reference
+// name points to, if advertised by the remote.
+func advertisedCommit(refs *packp.AdvRefs, name plumbing.ReferenceName) (plumbing.Hash, bool) {
+	// the remote advertises the commit
+	// annotated tags point to as peeled references
+	if hash, ok := refs.Peeled[name.String()]; ok {
+		return hash, true
+	}
+	hash, ok := refs.References[name.String()]
+	return hash, ok
+}
+
+// FetchRole clones the repository of Role r at its Version, returning
+// the working tree of the repository. Tags and branches are shallow cloned,
+// while the whole repository is cloned to check out commits. If the Role
+// has no Version, the default branch of the repository is cloned.
+func (g Git) FetchRole(ctx context.Context, r types.Role) (billy.Filesystem, error) {
+	var fs = memfs.New()
+	if len(r.Version) == 0 {
+		if _, err := g.clone(ctx, r, fs, &gogit.CloneOptions{Depth: 1, SingleBranch: true}); err != nil {
+			return nil, err
+		}
+		return fs, nil
 	}
 
-	// Fetch tags
-	tags, err := repo.Tags()
+	refs, err := g.advertisedReferences(ctx, r)
 	if err != nil {
-		return nil, fmt.Errorf("listing tags for %s: %v", r.Source, err)
+		return nil, err
+	}
+	for _, name := range []plumbing.ReferenceName{
+		plumbing.NewTagReferenceName(r.Version),
+		plumbing.NewBranchReferenceName(r.Version),
+	} {
+		if _, ok := refs.References[name.String()]; !ok {
+			continue
+		}
+		if _, err := g.clone(ctx, r, fs, &gogit.CloneOptions{ReferenceName: name, Depth: 1, SingleBranch: true}); err != nil {
+			return nil, err
+		}
+		return fs, nil
 	}
 
-	// Look for the most recent tag by commit date
-	var versions []string
-	err = tags.ForEach(func(tagRef *plumbing.Reference) error {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			versions = append(versions, tagRef.Name().Short())
+	// the version is not an advertised reference,
+	// so it can only be a commit of the repository
+	repo, err := g.clone(ctx, r, fs, &gogit.CloneOptions{NoCheckout: true})
+	if err != nil {
+		return nil, err
+	}
+	hash, err := resolveCommit(repo, r.Version)
+	if err != nil {
+		return nil, err
+	}
+	if hash.IsZero() {
+		return nil, errors.NewRoleVersionNotFoundError(r, tags(refs))
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	if err := wt.Checkout(&gogit.CheckoutOptions{Hash: hash}); err != nil {
+		return nil, fmt.Errorf("checking out %s of %s: %v", r.Version, r.Source, err)
+	}
+	return fs, nil
+}
+
+// clone clones the repository of Role r in memory, checking out its
+// working tree in fs. The URL and the authentication method of the
+// given CloneOptions are set to the ones of the repository.
+func (g Git) clone(ctx context.Context, r types.Role, fs billy.Filesystem, opts *gogit.CloneOptions) (*gogit.Repository, error) {
+	ep, auth, err := g.endpoint(r)
+	if err != nil {
+		return nil, err
+	}
+	opts.URL = ep.String()
+	opts.Auth = auth
+
+	repo, err := gogit.CloneContext(ctx, memory.NewStorage(), fs, opts)
+	if err != nil {
+		return nil, fmt.Errorf("cloning %s: %v", r.Source, err)
+	}
+	return repo, nil
+}
+
+// resolveCommit returns the hash of the commit of repo matching the
+// given revision, which can also be an abbreviated commit hash.
+// A zero hash is returned if no commit matches the revision.
+func resolveCommit(repo *gogit.Repository, revision string) (plumbing.Hash, error) {
+	if hash, err := repo.ResolveRevision(plumbing.Revision(revision)); err == nil {
+		return *hash, nil
+	}
+
+	// look for abbreviated commit hashes,
+	// which are not resolved by go-git
+	var commit plumbing.Hash
+	if len(revision) >= 4 {
+		commits, err := repo.CommitObjects()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		err = commits.ForEach(func(c *object.Commit) error {
+			if strings.HasPrefix(c.Hash.String(), revision) {
+				commit = c.Hash
+				return storer.ErrStop
+			}
 			return nil
+		})
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+	return commit, nil
+}
+
+// tags returns the names of the tags in
+// the given advertised references.
+func tags(refs *packp.AdvRefs) []string {
+	var tags []string
+	for name := range refs.References {
+		ref := plumbing.ReferenceName(name)
+		if ref.IsTag() {
+			tags = append(tags, ref.Short())
 		}
-	})
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// advertisedReferences returns the references advertised by
+// the remote repository of Role r, as listed by git ls-remote.
+func (g Git) advertisedReferences(ctx context.Context, r types.Role) (*packp.AdvRefs, error) {
+	ep, auth, err := g.endpoint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := client.NewClient(ep)
 	if err != nil {
 		return nil, err
 	}
 
-	return versions, nil
+	session, err := c.NewUploadPackSession(ep, auth)
+	if err != nil {
+		return nil, g.sessionError(r, err)
+	}
+	defer session.Close()
+
+	// the transport does not support contexts, so we make
+	// sure to close the session when the context is done
+	type result struct {
+		refs *packp.AdvRefs
+		err  error
+	}
+	var done = make(chan result, 1)
+	go func() {
+		refs, err := session.AdvertisedReferences()
+		done <- result{refs: refs, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-done:
+		if res.err == transport.ErrEmptyRemoteRepository {
+			// an empty repository has no references
+			return packp.NewAdvRefs(), nil
+		}
+		if res.err != nil {
+			return nil, g.sessionError(r, res.err)
+		}
+		return res.refs, nil
+	}
+}
+
+// endpoint returns the endpoint of the remote repository
+// of Role r, and the method to authenticate to it.
+func (g Git) endpoint(r types.Role) (*transport.Endpoint, transport.AuthMethod, error) {
+	ep, err := transport.NewEndpoint(GitURL(r.Source))
+	if err != nil {
+		return nil, nil, err
+	}
+	auth, err := g.authMethod(ep)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ep, auth, nil
+}
+
+// sessionError converts the errors returned
+// by the Git transport while listing the
+// references of the repository of Role r.
+func (g Git) sessionError(r types.Role, err error) error {
+	switch {
+	case err == transport.ErrRepositoryNotFound:
+		return errors.NewRoleNotFoundError(r, r.Source)
+	case err == transport.ErrAuthenticationRequired || err == transport.ErrAuthorizationFailed:
+		return fmt.Errorf("listing references of %s: %v, check the credentials configured for the host", strings.TrimSpace(r.Source), err)
+	default:
+		return fmt.Errorf("listing references of %s: %v", strings.TrimSpace(r.Source), err)
+	}
 }
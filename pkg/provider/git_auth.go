@@ -0,0 +1,264 @@
+package provider
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	githttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	gitssh "gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+)
+
+// DefaultGitTokenUsername is the username sent together with
+// the tokens used to access Git repositories over HTTPS, which
+// is ignored by most Git hosting services.
+const DefaultGitTokenUsername = "git"
+
+// GitCredentials holds the credentials used to access
+// the repositories hosted on a Git server.
+type GitCredentials struct {
+	// Host is the host name of the Git server.
+	Host string `yaml:"host"`
+
+	// Username is the user used to access the repositories,
+	// over SSH it defaults to the user of the URL or git.
+	Username string `yaml:"username"`
+
+	// Password is the password used to access
+	// the repositories over HTTPS.
+	Password string `yaml:"password"`
+
+	// Token is the access token used to access
+	// the repositories over HTTPS.
+	Token string `yaml:"token"`
+
+	// IdentityFile is the path of the private key used to access
+	// the repositories over SSH, and IdentityPassphrase its passphrase.
+	// When not set, the keys of the SSH agent are used, if running,
+	// and the default SSH keys otherwise.
+	IdentityFile       string `yaml:"identity_file"`
+	IdentityPassphrase string `yaml:"identity_passphrase"`
+
+	// KnownHostsFile is the path of the known_hosts file used to
+	// verify the server host key. When not set, the files listed by
+	// SSH_KNOWN_HOSTS or the default known_hosts files are used.
+	KnownHostsFile string `yaml:"known_hosts_file"`
+}
+
+// scpLikeURL matches the scp-like syntax of
+// Git URLs (e.g. git@github.com:org/repo.git).
+var scpLikeURL = regexp.MustCompile(`^(?:([^@/:]+)@)?([^@/:]{2,}):([^/].*)$`)
+
+// GitURL converts the source of a role to the URL of its Git
+// repository, removing the git+ prefix accepted by ansible-galaxy
+// and converting the scp-like syntax to an ssh:// URL.
+func GitURL(source string) string {
+	source = strings.TrimSpace(source)
+	source = strings.TrimPrefix(source, "git+")
+	if strings.Contains(source, "://") {
+		return source
+	}
+	if m := scpLikeURL.FindStringSubmatch(source); m != nil {
+		var user = ""
+		if len(m[1]) != 0 {
+			user = m[1] + "@"
+		}
+		return "ssh://" + user + m[2] + "/" + m[3]
+	}
+	return source
+}
+
+// authMethod returns the method used to authenticate
+// to the Git server of the endpoint ep.
+func (g Git) authMethod(ep *transport.Endpoint) (transport.AuthMethod, error) {
+	var creds GitCredentials
+	for _, c := range g.credentials {
+		if strings.EqualFold(c.Host, ep.Host) {
+			creds = c
+			break
+		}
+	}
+
+	switch ep.Protocol {
+	case "ssh":
+		return sshAuthMethod(ep, creds)
+	case "http", "https":
+		return httpAuthMethod(ep, creds)
+	default:
+		return nil, nil
+	}
+}
+
+// sshAuthMethod returns the method used to authenticate
+// to the SSH endpoint ep with the given credentials.
+func sshAuthMethod(ep *transport.Endpoint, creds GitCredentials) (transport.AuthMethod, error) {
+	var user = creds.Username
+	if len(user) == 0 {
+		user = ep.User
+	}
+	if len(user) == 0 {
+		user = "git"
+	}
+
+	var knownHosts []string
+	if len(creds.KnownHostsFile) != 0 {
+		knownHosts = append(knownHosts, expandHome(creds.KnownHostsFile))
+	}
+	hostKeyCallback, err := gitssh.NewKnownHostsCallback(knownHosts...)
+	if err != nil {
+		return nil, fmt.Errorf("loading the known hosts of %s: %v", ep.Host, err)
+	}
+
+	var identityFile = expandHome(creds.IdentityFile)
+	switch {
+	case len(identityFile) != 0:
+		// explicit key files take precedence
+		// over the keys of the SSH agent
+	case len(os.Getenv("SSH_AUTH_SOCK")) != 0:
+		auth, err := gitssh.NewSSHAgentAuth(user)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to the SSH agent: %v", err)
+		}
+		auth.HostKeyCallback = hostKeyCallback
+		return auth, nil
+	default:
+		// look for the default SSH keys
+		for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+			f := expandHome(filepath.Join("~", ".ssh", name))
+			if _, err := os.Stat(f); err == nil {
+				identityFile = f
+				break
+			}
+		}
+	}
+	if len(identityFile) == 0 {
+		// no key is available, so we let the
+		// transport fail to authenticate
+		return nil, nil
+	}
+
+	auth, err := gitssh.NewPublicKeysFromFile(user, identityFile, creds.IdentityPassphrase)
+	if err != nil {
+		return nil, fmt.Errorf("loading the SSH key %s: %v", identityFile, err)
+	}
+	auth.HostKeyCallback = hostKeyCallback
+	return auth, nil
+}
+
+// httpAuthMethod returns the method used to authenticate
+// to the HTTP endpoint ep with the given credentials. When
+// no credentials are configured for the server, the ones
+// in the netrc file are used, if any.
+func httpAuthMethod(ep *transport.Endpoint, creds GitCredentials) (transport.AuthMethod, error) {
+	switch {
+	case len(creds.Token) != 0:
+		var user = creds.Username
+		if len(user) == 0 {
+			user = DefaultGitTokenUsername
+		}
+		return &githttp.BasicAuth{Username: user, Password: creds.Token}, nil
+	case len(creds.Username) != 0:
+		return &githttp.BasicAuth{Username: creds.Username, Password: creds.Password}, nil
+	case len(ep.User) != 0:
+		// credentials in the URL are used by the transport
+		return nil, nil
+	}
+
+	login, password, err := netrcCredentials(netrcPath(), ep.Host)
+	if err != nil || len(login) == 0 {
+		return nil, err
+	}
+	return &githttp.BasicAuth{Username: login, Password: password}, nil
+}
+
+// netrcPath returns the path of the netrc file,
+// as configured by NETRC or defaulting to ~/.netrc.
+func netrcPath() string {
+	if path := os.Getenv("NETRC"); len(path) != 0 {
+		return path
+	}
+	return expandHome(filepath.Join("~", ".netrc"))
+}
+
+// netrcCredentials returns the login and password for host
+// in the netrc file at path, falling back to the default
+// entry. Missing netrc files are not an error.
+func netrcCredentials(path, host string) (string, string, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	type entry struct {
+		machine         string
+		login, password string
+	}
+
+	// remove the comments
+	var lines = strings.Split(string(data), "\n")
+	for i, l := range lines {
+		if strings.HasPrefix(strings.TrimSpace(l), "#") {
+			lines[i] = ""
+		}
+	}
+
+	var entries []entry
+	var tokens = strings.Fields(strings.Join(lines, "\n"))
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine":
+			if i+1 < len(tokens) {
+				entries = append(entries, entry{machine: tokens[i+1]})
+				i++
+			}
+		case "default":
+			entries = append(entries, entry{})
+		case "login", "password", "account":
+			if i+1 < len(tokens) && len(entries) != 0 {
+				switch tokens[i] {
+				case "login":
+					entries[len(entries)-1].login = tokens[i+1]
+				case "password":
+					entries[len(entries)-1].password = tokens[i+1]
+				}
+				i++
+			}
+		case "macdef":
+			// macros are not supported, and end the
+			// entries which can be parsed
+			i = len(tokens)
+		}
+	}
+
+	for _, e := range entries {
+		if strings.EqualFold(e.machine, host) {
+			return e.login, e.password, nil
+		}
+	}
+	for _, e := range entries {
+		if len(e.machine) == 0 {
+			return e.login, e.password, nil
+		}
+	}
+	return "", "", nil
+}
+
+// expandHome replaces the leading ~ of
+// path with the user home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[1:])
+}
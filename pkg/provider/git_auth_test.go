@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	githttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	gitssh "gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+)
+
+func TestGitURL(t *testing.T) {
+	cases := map[string]string{
+		"https://github.com/atosatto/ansible-minio":         "https://github.com/atosatto/ansible-minio",
+		"git+https://github.com/atosatto/ansible-minio.git": "https://github.com/atosatto/ansible-minio.git",
+		"git+ssh://git@github.com/atosatto/ansible-minio":   "ssh://git@github.com/atosatto/ansible-minio",
+		"git@github.com:atosatto/ansible-minio.git":         "ssh://git@github.com/atosatto/ansible-minio.git",
+		"git+git@github.com:atosatto/ansible-minio.git":     "ssh://git@github.com/atosatto/ansible-minio.git",
+		"gitlab.example.com:group/ansible-minio":            "ssh://gitlab.example.com/group/ansible-minio",
+		"atosatto.minio":                                    "atosatto.minio",
+		"/srv/git/ansible-minio":                            "/srv/git/ansible-minio",
+	}
+	for source, expected := range cases {
+		if u := GitURL(source); u != expected {
+			t.Errorf("%s: expected %s, got %s", source, expected, u)
+		}
+	}
+
+	// equivalent sources are normalized to the same value
+	if a, b := NormalizeSource("git@github.com:atosatto/ansible-minio.git"), NormalizeSource("git+ssh://git@github.com/atosatto/ansible-minio/"); a != b {
+		t.Errorf("expected equivalent sources to be normalized to the same value, got %s and %s", a, b)
+	}
+}
+
+func TestGitHTTPAuthMethod(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ansible-requirements-lint")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var netrc = filepath.Join(dir, "netrc")
+	ioutil.WriteFile(netrc, []byte(`
+# comment
+machine git.example.com login netrc-user password netrc-password
+default
+  login anonymous
+  password secret
+`), 0600)
+	os.Setenv("NETRC", netrc)
+	defer os.Unsetenv("NETRC")
+
+	g := NewGit(
+		GitCredentials{Host: "github.com", Token: "token"},
+		GitCredentials{Host: "bitbucket.org", Username: "user", Token: "app-password"},
+		GitCredentials{Host: "gitlab.com", Username: "user", Password: "password"},
+	)
+
+	cases := map[string]transport.AuthMethod{
+		"https://github.com/test/role":      &githttp.BasicAuth{Username: DefaultGitTokenUsername, Password: "token"},
+		"https://bitbucket.org/test/role":   &githttp.BasicAuth{Username: "user", Password: "app-password"},
+		"https://gitlab.com/test/role":      &githttp.BasicAuth{Username: "user", Password: "password"},
+		"https://git.example.com/test/role": &githttp.BasicAuth{Username: "netrc-user", Password: "netrc-password"},
+		"https://other.example.com/role":    &githttp.BasicAuth{Username: "anonymous", Password: "secret"},
+		"https://u:p@other.example.com/r":   nil,
+	}
+	for u, expected := range cases {
+		ep, err := transport.NewEndpoint(u)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", u, err)
+		}
+		auth, err := g.authMethod(ep)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", u, err)
+		}
+		if !reflect.DeepEqual(auth, expected) {
+			t.Errorf("%s: expected %v, got %v", u, expected, auth)
+		}
+	}
+}
+
+func TestGitSSHAuthMethod(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ansible-requirements-lint")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate the SSH key: %v", err)
+	}
+	var identityFile = filepath.Join(dir, "id_rsa")
+	ioutil.WriteFile(identityFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600)
+	var knownHosts = filepath.Join(dir, "known_hosts")
+	ioutil.WriteFile(knownHosts, nil, 0600)
+
+	g := NewGit(GitCredentials{Host: "github.com", IdentityFile: identityFile, KnownHostsFile: knownHosts})
+
+	for u, user := range map[string]string{
+		"git@github.com:test/role.git":   "git",
+		"ssh://deploy@github.com/t/role": "deploy",
+	} {
+		ep, err := transport.NewEndpoint(GitURL(u))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", u, err)
+		}
+		auth, err := g.authMethod(ep)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", u, err)
+		}
+		keys, ok := auth.(*gitssh.PublicKeys)
+		if !ok {
+			t.Fatalf("%s: expected public keys authentication, got %v", u, auth)
+		}
+		if keys.User != user || keys.HostKeyCallback == nil {
+			t.Errorf("%s: expected user %s with host key verification, got %+v", u, user, keys)
+		}
+	}
+
+	// unreadable keys are reported
+	g = NewGit(GitCredentials{Host: "github.com", IdentityFile: filepath.Join(dir, "missing"), KnownHostsFile: knownHosts})
+	ep, _ := transport.NewEndpoint("ssh://git@github.com/test/role")
+	if _, err := g.authMethod(ep); err == nil {
+		t.Errorf("expected an error loading a missing SSH key")
+	}
+}
@@ -0,0 +1,214 @@
+package provider
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-billy.v4/osfs"
+	gogit "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/cache"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
+)
+
+// gitFixture holds the commits of
+// the bare repository created by newGitFixture.
+type gitFixture struct {
+	path string
+
+	first, second, third plumbing.Hash
+}
+
+// newGitFixture creates a bare Git repository
+// in a temporary directory holding three commits:
+// the first tagged with the v1.0.0 lightweight tag,
+// the second with the v1.1.0 annotated tag and the
+// third on the develop branch.
+func newGitFixture(t *testing.T) *gitFixture {
+	dir, err := ioutil.TempDir("", "ansible-requirements-lint")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+
+	storer := filesystem.NewStorage(osfs.New(dir), cache.NewObjectLRUDefault())
+	repo, err := gogit.Init(storer, memfs.New())
+	if err != nil {
+		t.Fatalf("unable to init the repository: %v", err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("unable to get the worktree: %v", err)
+	}
+
+	var signature = &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	commit := func(content string) plumbing.Hash {
+		f, err := wt.Filesystem.Create("README.md")
+		if err != nil {
+			t.Fatalf("unable to create file: %v", err)
+		}
+		f.Write([]byte(content))
+		f.Close()
+		if _, err := wt.Add("README.md"); err != nil {
+			t.Fatalf("unable to add file: %v", err)
+		}
+		hash, err := wt.Commit(content, &gogit.CommitOptions{Author: signature})
+		if err != nil {
+			t.Fatalf("unable to commit: %v", err)
+		}
+		return hash
+	}
+
+	var fixture = &gitFixture{path: dir}
+	fixture.first = commit("first")
+	if _, err := repo.CreateTag("v1.0.0", fixture.first, nil); err != nil {
+		t.Fatalf("unable to create tag: %v", err)
+	}
+	fixture.second = commit("second")
+	if _, err := repo.CreateTag("v1.1.0", fixture.second, &gogit.CreateTagOptions{Tagger: signature, Message: "v1.1.0"}); err != nil {
+		t.Fatalf("unable to create tag: %v", err)
+	}
+	if err := wt.Checkout(&gogit.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("develop"), Create: true}); err != nil {
+		t.Fatalf("unable to create branch: %v", err)
+	}
+	fixture.third = commit("third")
+
+	return fixture
+}
+
+func TestGitVersionsForRole(t *testing.T) {
+	fixture := newGitFixture(t)
+	defer os.RemoveAll(fixture.path)
+
+	versions, err := NewGit().VersionsForRole(context.Background(), types.Role{Source: fixture.path})
+	if err != nil {
+		t.Fatalf("expected no error, obtained %+v", err)
+	}
+
+	expected := []string{"v1.0.0", "v1.1.0"}
+	if !reflect.DeepEqual(expected, versions) {
+		t.Errorf("expecting versions %v, obtained %v", expected, versions)
+	}
+}
+
+func TestGitBranchesForRole(t *testing.T) {
+	fixture := newGitFixture(t)
+	defer os.RemoveAll(fixture.path)
+
+	branches, err := NewGit().BranchesForRole(context.Background(), types.Role{Source: fixture.path})
+	if err != nil {
+		t.Fatalf("expected no error, obtained %+v", err)
+	}
+
+	expected := []string{"develop", "master"}
+	if !reflect.DeepEqual(expected, branches) {
+		t.Errorf("expecting branches %v, obtained %v", expected, branches)
+	}
+}
+
+func TestGitVersionsForRoleNotFound(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ansible-requirements-lint")
+	if err != nil {
+		t.Fatalf("unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, err = NewGit().VersionsForRole(context.Background(), types.Role{Source: dir + "/notfound"})
+	if !errors.IsRoleNotFoundError(err) {
+		t.Errorf("expecting a RoleNotFoundError, obtained %+v", err)
+	}
+}
+
+func TestGitCommitForVersion(t *testing.T) {
+	fixture := newGitFixture(t)
+	defer os.RemoveAll(fixture.path)
+
+	cases := map[string]plumbing.Hash{
+		"v1.0.0":                   fixture.first,
+		"v1.1.0":                   fixture.second,
+		"develop":                  fixture.third,
+		fixture.second.String():    fixture.second,
+		fixture.first.String()[:8]: fixture.first,
+	}
+
+	role := types.Role{Source: fixture.path}
+	for version, expected := range cases {
+		commit, err := NewGit().CommitForVersion(context.Background(), role, version)
+		if err != nil {
+			t.Errorf("%s: expected no error, obtained %+v", version, err)
+			continue
+		}
+		if commit != expected.String() {
+			t.Errorf("%s: expecting commit %s, obtained %s", version, expected, commit)
+		}
+	}
+
+	if _, err := NewGit().CommitForVersion(context.Background(), role, "v2.0.0"); !errors.IsRoleVersionNotFoundError(err) {
+		t.Errorf("expecting a RoleVersionNotFoundError, obtained %+v", err)
+	}
+}
+
+func TestGitCommitForTag(t *testing.T) {
+	fixture := newGitFixture(t)
+	defer os.RemoveAll(fixture.path)
+
+	role := types.Role{Source: fixture.path}
+	for tag, expected := range map[string]plumbing.Hash{"v1.0.0": fixture.first, "v1.1.0": fixture.second} {
+		commit, err := NewGit().CommitForTag(context.Background(), role, tag)
+		if err != nil {
+			t.Errorf("%s: expected no error, obtained %+v", tag, err)
+			continue
+		}
+		if commit != expected.String() {
+			t.Errorf("%s: expecting commit %s, obtained %s", tag, expected, commit)
+		}
+	}
+
+	// branches and commits are not tags
+	for _, version := range []string{"develop", fixture.first.String()} {
+		if _, err := NewGit().CommitForTag(context.Background(), role, version); !errors.IsRoleVersionNotFoundError(err) {
+			t.Errorf("%s: expecting a RoleVersionNotFoundError, obtained %+v", version, err)
+		}
+	}
+}
+
+func TestGitFetchRole(t *testing.T) {
+	fixture := newGitFixture(t)
+	defer os.RemoveAll(fixture.path)
+
+	cases := map[string]string{
+		"":                         "third",
+		"v1.0.0":                   "first",
+		"v1.1.0":                   "second",
+		"develop":                  "third",
+		fixture.first.String()[:8]: "first",
+	}
+	for version, expected := range cases {
+		fs, err := NewGit().FetchRole(context.Background(), types.Role{Source: fixture.path, Version: version})
+		if err != nil {
+			t.Errorf("%s: expected no error, obtained %+v", version, err)
+			continue
+		}
+		f, err := fs.Open("README.md")
+		if err != nil {
+			t.Errorf("%s: unable to open README.md: %v", version, err)
+			continue
+		}
+		content, _ := ioutil.ReadAll(f)
+		f.Close()
+		if string(content) != expected {
+			t.Errorf("%s: expecting content %q, obtained %q", version, expected, content)
+		}
+	}
+
+	if _, err := NewGit().FetchRole(context.Background(), types.Role{Source: fixture.path, Version: "v2.0.0"}); !errors.IsRoleVersionNotFoundError(err) {
+		t.Errorf("expecting a RoleVersionNotFoundError, obtained %+v", err)
+	}
+}
@@ -2,8 +2,11 @@ package provider
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+	"gopkg.in/src-d/go-billy.v4"
 )
 
 // The RolesProvider interface define some methods to fetch
@@ -12,3 +15,120 @@ import (
 type RolesProvider interface {
 	VersionsForRole(ctx context.Context, r types.Role) ([]string, error)
 }
+
+// The CollectionsProvider interface define some methods to fetch
+// information on Ansible Collections updates from upstream providers
+// such as Ansible Galaxy.
+type CollectionsProvider interface {
+	VersionsForCollection(ctx context.Context, c types.Collection) ([]string, error)
+}
+
+// The RolesFetcher interface is implemented by the RolesProviders
+// able to download the content of a role, or at least its meta directory,
+// at the version it is pinned to, as installed by ansible-galaxy. Roles
+// without a version are fetched at the default branch of their repository,
+// unless they have versions, in which case the version to be fetched is to
+// be selected by the caller.
+type RolesFetcher interface {
+	FetchRole(ctx context.Context, r types.Role) (billy.Filesystem, error)
+}
+
+// Artifact is the archive a version of a
+// role or collection is installed from.
+type Artifact struct {
+	// Version is the version of the
+	// role or collection in the archive.
+	Version string
+
+	// URL is the location the
+	// archive is downloaded from.
+	URL string
+
+	// Checksum is the hex encoded
+	// SHA-256 checksum of the archive.
+	Checksum string
+}
+
+// The RoleArtifactsProvider interface is implemented by the RolesProviders
+// distributing roles as archives, such as Ansible Galaxy. Roles without a
+// version are resolved to the version ansible-galaxy would install.
+type RoleArtifactsProvider interface {
+	RoleArtifact(ctx context.Context, r types.Role) (Artifact, error)
+}
+
+// The CollectionArtifactsProvider interface is implemented by the CollectionsProviders
+// distributing collections as archives, such as Ansible Galaxy. The version of
+// the collections must be an exact version.
+type CollectionArtifactsProvider interface {
+	CollectionArtifact(ctx context.Context, c types.Collection) (Artifact, error)
+}
+
+// Lookup holds the versions of a role or collection
+// together with information on where they come from.
+type Lookup struct {
+	// Versions is the list of versions
+	// available for the role or collection.
+	Versions []string
+
+	// FetchedAt is the time the versions have
+	// been fetched from the upstream provider.
+	FetchedAt time.Time
+
+	// Stale is true when the versions have been
+	// fetched too long ago to be considered up to date.
+	Stale bool
+
+	// Server is the name of the server the
+	// versions have been fetched from, if known.
+	Server string
+}
+
+// The RolesLookupProvider interface is implemented by the RolesProviders
+// able to report additional information on the versions they return.
+type RolesLookupProvider interface {
+	LookupRole(ctx context.Context, r types.Role) (Lookup, error)
+}
+
+// The CollectionsLookupProvider interface is implemented by the CollectionsProviders
+// able to report additional information on the versions they return.
+type CollectionsLookupProvider interface {
+	LookupCollection(ctx context.Context, c types.Collection) (Lookup, error)
+}
+
+// LookupRole returns the versions available for the Role r using the provider p.
+// If p does not implement the RolesLookupProvider interface, the versions are
+// considered fetched from the upstream provider at the time of the call.
+func LookupRole(ctx context.Context, p RolesProvider, r types.Role) (Lookup, error) {
+	if l, ok := p.(RolesLookupProvider); ok {
+		return l.LookupRole(ctx, r)
+	}
+	versions, err := p.VersionsForRole(ctx, r)
+	if err != nil {
+		return Lookup{}, err
+	}
+	return Lookup{Versions: versions, FetchedAt: time.Now()}, nil
+}
+
+// LookupCollection returns the versions available for the Collection c using the provider p.
+// If p does not implement the CollectionsLookupProvider interface, the versions are
+// considered fetched from the upstream provider at the time of the call.
+func LookupCollection(ctx context.Context, p CollectionsProvider, c types.Collection) (Lookup, error) {
+	if l, ok := p.(CollectionsLookupProvider); ok {
+		return l.LookupCollection(ctx, c)
+	}
+	versions, err := p.VersionsForCollection(ctx, c)
+	if err != nil {
+		return Lookup{}, err
+	}
+	return Lookup{Versions: versions, FetchedAt: time.Now()}, nil
+}
+
+// NormalizeSource normalizes the source of a role or collection,
+// so that equivalent sources (e.g. with or without the .git suffix,
+// or using the scp-like syntax of Git URLs) can be compared.
+func NormalizeSource(source string) string {
+	source = GitURL(source)
+	source = strings.TrimSuffix(source, "/")
+	source = strings.TrimSuffix(source, ".git")
+	return source
+}
@@ -3,15 +3,39 @@ package types
 // Requirements represents the content
 // Ansible Requirements file.
 type Requirements struct {
+	// File is the path of the Requirements file.
+	// It is empty when the Requirements have not
+	// been parsed from a file.
+	File string
+
 	// Roles is the list of roles defined
 	// in the Requirements file.
 	Roles []Role
 
+	// Collections is the list of collections
+	// defined in the Requirements file.
+	Collections []Collection
+
 	// Children is the list of requirements
 	// files included by the Requirement file.
 	Childrens []*Requirements
 }
 
+// Walk calls fn for the Requirements and, recursively,
+// for all the Requirements files it includes.
+// The walk stops at the first error returned by fn.
+func (r *Requirements) Walk(fn func(*Requirements) error) error {
+	if err := fn(r); err != nil {
+		return err
+	}
+	for _, c := range r.Childrens {
+		if err := c.Walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Role is an Ansible Role definition.
 // If the value of the Include is different
 // from the nil string, it means that
@@ -25,4 +49,95 @@ type Role struct {
 	Name    string
 
 	Include string
+
+	// Commit is the hash, possibly abbreviated, of the commit
+	// the Version has been recorded to point to by a comment
+	// following the version (e.g. "version: v1.0.0 # commit: 5f1e2d3").
+	// It is empty when no commit has been recorded.
+	Commit string
+
+	// Position is the location of the
+	// Role definition in the requirements file.
+	Position Position
+
+	// Via is the chain of roles through which the Role has
+	// been pulled in as a transitive dependency, starting from
+	// a role declared in the requirements file. It is empty for
+	// the roles declared in the requirements file.
+	Via []Role
+}
+
+// CollectionType is the type of the source
+// an Ansible Collection is installed from.
+type CollectionType string
+
+const (
+	// CollectionTypeGalaxy is used for collections
+	// installed from an Ansible Galaxy server.
+	CollectionTypeGalaxy = CollectionType("galaxy")
+
+	// CollectionTypeGit is used for collections
+	// installed from a Git repository.
+	CollectionTypeGit = CollectionType("git")
+
+	// CollectionTypeURL is used for collections
+	// distributed as tarballs on a web server.
+	CollectionTypeURL = CollectionType("url")
+
+	// CollectionTypeFile is used for collections
+	// installed from a tarball on the local filesystem.
+	CollectionTypeFile = CollectionType("file")
+
+	// CollectionTypeDir is used for collections
+	// installed from a directory on the local filesystem.
+	CollectionTypeDir = CollectionType("dir")
+)
+
+// Collection is an Ansible Collection definition.
+// Version holds the version constraint declared for the
+// Collection (e.g. 1.0.0 or >=1.0.0,<2.0.0), and it is
+// empty when any version of the Collection is accepted.
+type Collection struct {
+	Name    string
+	Version string
+	Source  string
+	Type    CollectionType
+
+	// Position is the location of the
+	// Collection definition in the requirements file.
+	Position Position
+}
+
+// Position is the location of a Role or Collection
+// definition in a requirements file.
+// Lines and columns are 1-based, and zero
+// when the location is unknown.
+type Position struct {
+	// File is the path of the requirements file.
+	File string
+
+	// Line and Column are the location of
+	// the start of the definition.
+	Line   int
+	Column int
+
+	// Version is the location of the version value, quotes
+	// included. It is zero when no version has been declared.
+	Version Span
+}
+
+// Span is a range of text in a requirements file.
+// The end of the Span is exclusive, so that a Span
+// holding the "v1.0.0" value in "version: v1.0.0"
+// has Column 10 and EndColumn 16.
+type Span struct {
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+}
+
+// IsZero reports whether the Span is unknown.
+func (s Span) IsZero() bool {
+	return s.Line == 0
 }
@@ -0,0 +1,97 @@
+package writer
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/linter"
+)
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr,omitempty"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// CheckstyleWriter writes Linters results in the Checkstyle XML format.
+// The source of each error is the stable ID of the matching rule,
+// prefixed by ansible-requirements-lint.
+// Results with LevelInfo are only written when Verbose is true.
+type CheckstyleWriter struct {
+	Verbose bool
+}
+
+// WriteUpdates writes Linters results in Checkstyle XML format to the given io.Writer
+func (c CheckstyleWriter) WriteUpdates(ctx context.Context, w io.Writer, input <-chan linter.Result) error {
+	var report = checkstyleReport{Version: "4.3"}
+
+	// index of the file element of each requirements file
+	var files = make(map[string]int)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case res, more := <-input:
+			// write the report when there are no more input results
+			if !more {
+				if _, err := io.WriteString(w, xml.Header); err != nil {
+					return err
+				}
+				enc := xml.NewEncoder(w)
+				enc.Indent("", "  ")
+				if err := enc.Encode(report); err != nil {
+					return err
+				}
+				_, err := io.WriteString(w, "\n")
+				return err
+			}
+
+			if res.Level == linter.LevelInfo && !c.Verbose {
+				continue
+			}
+
+			var pos = res.Position()
+			i, ok := files[pos.File]
+			if !ok {
+				i = len(report.Files)
+				files[pos.File] = i
+				report.Files = append(report.Files, checkstyleFile{Name: pos.File})
+			}
+
+			report.Files[i].Errors = append(report.Files[i].Errors, checkstyleError{
+				Line:     pos.Line,
+				Column:   pos.Column,
+				Severity: checkstyleSeverity(res.Level),
+				Message:  resultName(res) + ": " + resultMessage(res) + ".",
+				Source:   "ansible-requirements-lint." + ruleID(res),
+			})
+		}
+	}
+}
+
+// checkstyleSeverity converts a linter.Level to a Checkstyle severity.
+func checkstyleSeverity(l linter.Level) string {
+	switch l {
+	case linter.LevelError:
+		return "error"
+	case linter.LevelWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
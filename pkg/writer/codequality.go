@@ -0,0 +1,82 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/linter"
+)
+
+type codeQualityIssue struct {
+	Description string              `json:"description"`
+	CheckName   string              `json:"check_name"`
+	Fingerprint string              `json:"fingerprint"`
+	Severity    string              `json:"severity"`
+	Location    codeQualityLocation `json:"location"`
+}
+
+type codeQualityLocation struct {
+	Path  string           `json:"path"`
+	Lines codeQualityLines `json:"lines"`
+}
+
+type codeQualityLines struct {
+	Begin int `json:"begin"`
+}
+
+// CodeQualityWriter writes Linters results as a GitLab Code Quality report.
+// Each issue has a fingerprint which is stable across runs, allowing
+// GitLab to track new and fixed issues in merge requests.
+// Results with LevelInfo are only written when Verbose is true.
+type CodeQualityWriter struct {
+	Verbose bool
+}
+
+// WriteUpdates writes Linters results in GitLab Code Quality format to the given io.Writer
+func (c CodeQualityWriter) WriteUpdates(ctx context.Context, w io.Writer, input <-chan linter.Result) error {
+	var issues = []codeQualityIssue{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case res, more := <-input:
+			// write the report when there are no more input results
+			if !more {
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				return enc.Encode(issues)
+			}
+
+			if res.Level == linter.LevelInfo && !c.Verbose {
+				continue
+			}
+
+			var pos = res.Position()
+			issues = append(issues, codeQualityIssue{
+				Description: resultName(res) + ": " + resultMessage(res) + ".",
+				CheckName:   ruleID(res),
+				Fingerprint: fingerprint(res),
+				Severity:    codeQualitySeverity(res.Level),
+				Location: codeQualityLocation{
+					Path:  filepath.ToSlash(pos.File),
+					Lines: codeQualityLines{Begin: pos.Line},
+				},
+			})
+		}
+	}
+}
+
+// codeQualitySeverity converts a linter.Level to a GitLab Code Quality severity.
+func codeQualitySeverity(l linter.Level) string {
+	switch l {
+	case linter.LevelError:
+		return "major"
+	case linter.LevelWarning:
+		return "minor"
+	default:
+		return "info"
+	}
+}
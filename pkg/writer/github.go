@@ -0,0 +1,131 @@
+package writer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/linter"
+)
+
+// GitHubWriter writes Linters results as GitHub Actions
+// workflow commands, so that they are reported as annotations
+// of the requirements files. Results with LevelInfo are skipped.
+// If StepSummary is set, a Markdown table summarizing the results
+// is appended to the file at the given path, which is expected
+// to be the one referenced by the GITHUB_STEP_SUMMARY variable.
+type GitHubWriter struct {
+	Verbose     bool
+	StepSummary string
+}
+
+// WriteUpdates writes Linters results as GitHub Actions workflow commands to the given io.Writer
+func (g GitHubWriter) WriteUpdates(ctx context.Context, w io.Writer, input <-chan linter.Result) error {
+	var summary strings.Builder
+	summary.WriteString("| Location | Name | Current Version | Latest Version | Status |\n")
+	summary.WriteString("| --- | --- | --- | --- | --- |\n")
+	var rows = 0
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case res, more := <-input:
+			// write the job summary when there are no more input results
+			if !more {
+				if len(g.StepSummary) == 0 {
+					return nil
+				}
+				if rows == 0 {
+					summary.Reset()
+					summary.WriteString("No issues found.\n")
+				}
+				return appendToFile(g.StepSummary, "## ansible-requirements-lint\n\n"+summary.String()+"\n")
+			}
+
+			if res.Level != linter.LevelInfo {
+				if _, err := fmt.Fprintln(w, githubCommand(res)); err != nil {
+					return err
+				}
+			}
+
+			if res.Level != linter.LevelInfo || g.Verbose {
+				var version = resultVersion(res)
+				if len(version) == 0 {
+					version = "-"
+				}
+				var latest = metadataToUpdate(res).ToVersion
+				if len(latest) == 0 {
+					latest = "-"
+				}
+				fmt.Fprintf(&summary, "| %s | %s | %s | %s | %s |\n",
+					markdownEscape(resultLocation(res)),
+					markdownEscape(resultName(res)),
+					markdownEscape(version),
+					markdownEscape(latest),
+					markdownEscape(string(res.Level)+": "+resultMessage(res)))
+				rows++
+			}
+		}
+	}
+}
+
+// githubCommand returns the GitHub Actions workflow
+// command annotating the file with the given Result.
+func githubCommand(res linter.Result) string {
+	var command = "warning"
+	if res.Level == linter.LevelError {
+		command = "error"
+	}
+
+	var pos = res.Position()
+	var properties []string
+	if len(pos.File) != 0 {
+		properties = append(properties, "file="+githubEscapeProperty(pos.File))
+		if !pos.Version.IsZero() {
+			properties = append(properties,
+				fmt.Sprintf("line=%d", pos.Version.Line),
+				fmt.Sprintf("col=%d", pos.Version.Column),
+				fmt.Sprintf("endColumn=%d", pos.Version.EndColumn))
+		} else if pos.Line != 0 {
+			properties = append(properties,
+				fmt.Sprintf("line=%d", pos.Line),
+				fmt.Sprintf("col=%d", pos.Column))
+		}
+	}
+	properties = append(properties, "title="+githubEscapeProperty(resultName(res)))
+
+	return fmt.Sprintf("::%s %s::%s", command, strings.Join(properties, ","), githubEscapeData(resultMessage(res)+"."))
+}
+
+// githubEscapeData escapes the data of a workflow command.
+func githubEscapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// githubEscapeProperty escapes the value of
+// a property of a workflow command.
+func githubEscapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// markdownEscape escapes s to be used in a Markdown table cell.
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
+
+// appendToFile appends content to the file
+// at path, creating the file if needed.
+func appendToFile(path string, content string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
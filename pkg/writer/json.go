@@ -0,0 +1,160 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/linter"
+)
+
+// jsonResult is the JSON representation of a linter.Result.
+type jsonResult struct {
+	Type            string   `json:"type,omitempty"`
+	Kind            string   `json:"kind"`
+	Name            string   `json:"name"`
+	Source          string   `json:"source,omitempty"`
+	Scm             string   `json:"scm,omitempty"`
+	CollectionType  string   `json:"collection_type,omitempty"`
+	File            string   `json:"file,omitempty"`
+	Line            int      `json:"line,omitempty"`
+	Column          int      `json:"column,omitempty"`
+	CurrentVersion  string   `json:"current_version"`
+	LatestVersion   string   `json:"latest_version,omitempty"`
+	LatestAllowed   string   `json:"latest_allowed_version,omitempty"`
+	UpdateAvailable bool     `json:"update_available"`
+	IgnoredTags     []string `json:"ignored_tags,omitempty"`
+	StaleAsOf       string   `json:"stale_as_of,omitempty"`
+	Server          string   `json:"server,omitempty"`
+	Via             []string `json:"via,omitempty"`
+	Level           string   `json:"level"`
+	ErrorKind       string   `json:"error_kind,omitempty"`
+	Message         string   `json:"message"`
+}
+
+// jsonSummary is the JSON representation of
+// the summary of the Linters results.
+type jsonSummary struct {
+	Type     string `json:"type,omitempty"`
+	Total    int    `json:"total"`
+	Info     int    `json:"info"`
+	Warnings int    `json:"warnings"`
+	Errors   int    `json:"errors"`
+	Updates  int    `json:"updates"`
+}
+
+// add updates the summary with the given Result.
+func (s *jsonSummary) add(res linter.Result) {
+	s.Total++
+	switch res.Level {
+	case linter.LevelError:
+		s.Errors++
+	case linter.LevelWarning:
+		s.Warnings++
+	default:
+		s.Info++
+	}
+	if metadataToUpdate(res).IsUpdate {
+		s.Updates++
+	}
+}
+
+// newJSONResult converts a linter.Result to its JSON representation.
+func newJSONResult(res linter.Result) jsonResult {
+	var pos = res.Position()
+	var meta = metadataToUpdate(res)
+	var r = jsonResult{
+		Kind:            resultKind(res),
+		Name:            resultName(res),
+		File:            pos.File,
+		Line:            pos.Line,
+		Column:          pos.Column,
+		CurrentVersion:  resultVersion(res),
+		LatestVersion:   meta.LatestVersion,
+		LatestAllowed:   meta.ToVersion,
+		UpdateAvailable: meta.IsUpdate,
+		IgnoredTags:     meta.IgnoredTags,
+		Server:          meta.Server,
+		Via:             resultVia(res),
+		Level:           string(res.Level),
+		ErrorKind:       errorKind(res),
+		Message:         resultMessage(res),
+	}
+	if len(r.LatestVersion) == 0 {
+		r.LatestVersion = meta.ToVersion
+	}
+	if !meta.StaleAsOf.IsZero() {
+		r.StaleAsOf = meta.StaleAsOf.Format(time.RFC3339)
+	}
+	if isCollection(res) {
+		r.Source = res.Collection.Source
+		r.CollectionType = string(res.Collection.Type)
+	} else {
+		r.Source = res.Role.Source
+		r.Scm = res.Role.Scm
+	}
+	return r
+}
+
+// JSONWriter writes Linters results as a single JSON document
+// holding the list of results and a summary of them.
+type JSONWriter struct{}
+
+// WriteUpdates writes Linters results in JSON format to the given io.Writer
+func (j JSONWriter) WriteUpdates(ctx context.Context, w io.Writer, input <-chan linter.Result) error {
+	var doc = struct {
+		Results []jsonResult `json:"results"`
+		Summary jsonSummary  `json:"summary"`
+	}{
+		Results: []jsonResult{},
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case res, more := <-input:
+			// write the document when there are no more input results
+			if !more {
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				return enc.Encode(doc)
+			}
+
+			doc.Results = append(doc.Results, newJSONResult(res))
+			doc.Summary.add(res)
+		}
+	}
+}
+
+// JSONLinesWriter writes Linters results in the newline-delimited
+// JSON format, a JSON object per line. Each result is written as
+// soon as it is received, and a summary object is written last.
+// Objects are told apart by their type field, either result or summary.
+type JSONLinesWriter struct{}
+
+// WriteUpdates writes Linters results in newline-delimited JSON format to the given io.Writer
+func (j JSONLinesWriter) WriteUpdates(ctx context.Context, w io.Writer, input <-chan linter.Result) error {
+	var enc = json.NewEncoder(w)
+	var summary = jsonSummary{Type: "summary"}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case res, more := <-input:
+			// write the summary when there are no more input results
+			if !more {
+				return enc.Encode(summary)
+			}
+
+			r := newJSONResult(res)
+			r.Type = "result"
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+			summary.add(res)
+		}
+	}
+}
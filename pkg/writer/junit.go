@@ -0,0 +1,101 @@
+package writer
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/linter"
+)
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnitWriter writes Linters results in the JUnit XML format.
+// Each requirements file is reported as a testsuite and each
+// role or collection as a testcase, failing when the Result
+// has LevelError or LevelWarning.
+type JUnitWriter struct{}
+
+// WriteUpdates writes Linters results in JUnit XML format to the given io.Writer
+func (j JUnitWriter) WriteUpdates(ctx context.Context, w io.Writer, input <-chan linter.Result) error {
+	var doc = junitTestSuites{Name: "ansible-requirements-lint"}
+
+	// index of the testsuite of each requirements file
+	var suites = make(map[string]int)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case res, more := <-input:
+			// write the report when there are no more input results
+			if !more {
+				if _, err := io.WriteString(w, xml.Header); err != nil {
+					return err
+				}
+				enc := xml.NewEncoder(w)
+				enc.Indent("", "  ")
+				if err := enc.Encode(doc); err != nil {
+					return err
+				}
+				_, err := io.WriteString(w, "\n")
+				return err
+			}
+
+			var file = res.Position().File
+			i, ok := suites[file]
+			if !ok {
+				i = len(doc.TestSuites)
+				suites[file] = i
+				doc.TestSuites = append(doc.TestSuites, junitTestSuite{Name: file})
+			}
+			var suite = &doc.TestSuites[i]
+
+			var testCase = junitTestCase{
+				Name:      resultName(res),
+				ClassName: file,
+			}
+			if res.Level != linter.LevelInfo {
+				var message = resultMessage(res)
+				if res.Err != nil {
+					message = res.Err.Error()
+				}
+				testCase.Failure = &junitFailure{
+					Message: message,
+					Type:    ruleID(res),
+					Text:    string(res.Level) + ": " + resultMessage(res) + ".",
+				}
+				suite.Failures++
+				doc.Failures++
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+			suite.Tests++
+			doc.Tests++
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package writer
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/linter"
+)
+
+// rule describes a kind of Linter result
+// reported by the machine-readable writers.
+type rule struct {
+	ID          string
+	Description string
+}
+
+// rules is the list of the known rules.
+// IDs must be kept stable as they are used
+// by external tools to track the results.
+var rules = []rule{
+	{ID: "update-available", Description: "A more recent version of the dependency is available."},
+	{ID: "version-not-found", Description: "The version of the dependency is not among the available ones."},
+	{ID: "role-not-found", Description: "The role cannot be found on the upstream source."},
+	{ID: "collection-not-found", Description: "The collection cannot be found on the upstream source."},
+	{ID: "invalid-role-name", Description: "The name of the Ansible Galaxy role is not in the namespace.name format."},
+	{ID: "unknown-scm", Description: "The role uses an unknown or unsupported scm."},
+	{ID: "unknown-collection-type", Description: "The collection uses an unknown or unsupported type."},
+	{ID: "not-cached", Description: "The versions of the dependency are not available in offline mode."},
+	{ID: "dependency-conflict", Description: "The role is required at conflicting versions across the dependency graph."},
+	{ID: "dependencies-not-resolved", Description: "The transitive dependencies of the role cannot be resolved."},
+	{ID: "not-locked", Description: "The dependency is not locked at the version of the requirements file."},
+	{ID: "lock-mismatch", Description: "The locked version of the dependency has changed upstream."},
+	{ID: "tag-moved", Description: "The tag the role is pinned to has been moved to a different commit."},
+	{ID: "unpinned-version", Description: "The role is not pinned to a kind of version allowed by the pinning policy."},
+	{ID: "no-releases", Description: "None of the tags of the dependency is a version."},
+	{ID: "up-to-date", Description: "The dependency is at the latest version."},
+	{ID: "error", Description: "The dependency cannot be checked."},
+}
+
+// ruleID returns the ID of the rule
+// matching the given Result.
+func ruleID(res linter.Result) string {
+	switch {
+	case res.Err != nil:
+		return errorKind(res)
+	case metadataToUpdate(res).IsUpdate:
+		return "update-available"
+	default:
+		return "up-to-date"
+	}
+}
+
+// ruleIndex returns the index of the rule
+// with the given ID in the rules list.
+func ruleIndex(id string) int {
+	for i, r := range rules {
+		if r.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// fingerprint returns an identifier of the given Result which
+// is stable across runs. It is derived from the requirements file,
+// the identity of the role or collection, the Linter and the matching
+// rule, so that it does not change when, for instance, the version
+// of the role is updated but the Result is still the same, while the
+// Results of different Linters on the same role are kept apart.
+func fingerprint(res linter.Result) string {
+	var source = res.Role.Source
+	if isCollection(res) {
+		source = res.Collection.Source
+	}
+	var identity = strings.Join([]string{
+		filepath.ToSlash(res.Position().File),
+		resultKind(res),
+		resultName(res),
+		source,
+		res.Linter,
+		ruleID(res),
+	}, "\x00")
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(identity)))
+}
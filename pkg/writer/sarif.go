@@ -0,0 +1,193 @@
+package writer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/linter"
+)
+
+const (
+	// sarifVersion is the version of the SARIF specification
+	// implemented by the SARIFWriter.
+	sarifVersion = "2.1.0"
+
+	// sarifSchema is the JSON schema of the SARIF format.
+	sarifSchema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// toolURI is the URI of the ansible-requirements-lint project.
+	toolURI = "https://github.com/atosatto/ansible-requirements-lint"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// SARIFWriter writes Linters results in the SARIF 2.1.0 format,
+// supported by GitHub code scanning.
+// Results with LevelInfo are only written when Verbose is true.
+type SARIFWriter struct {
+	Verbose bool
+
+	// Version is the version of ansible-requirements-lint
+	// reported in the SARIF log.
+	Version string
+}
+
+// WriteUpdates writes Linters results in SARIF format to the given io.Writer
+func (s SARIFWriter) WriteUpdates(ctx context.Context, w io.Writer, input <-chan linter.Result) error {
+	var run = sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           "ansible-requirements-lint",
+				Version:        s.Version,
+				InformationURI: toolURI,
+			},
+		},
+		Results: []sarifResult{},
+	}
+	for _, r := range rules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:               r.ID,
+			ShortDescription: sarifMessage{Text: r.Description},
+		})
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case res, more := <-input:
+			// write the log when there are no more input results
+			if !more {
+				enc := json.NewEncoder(w)
+				enc.SetIndent("", "  ")
+				return enc.Encode(sarifLog{
+					Schema:  sarifSchema,
+					Version: sarifVersion,
+					Runs:    []sarifRun{run},
+				})
+			}
+
+			if res.Level == linter.LevelInfo && !s.Verbose {
+				continue
+			}
+
+			var id = ruleID(res)
+			var r = sarifResult{
+				RuleID:    id,
+				RuleIndex: ruleIndex(id),
+				Level:     sarifLevel(res.Level),
+				Message:   sarifMessage{Text: resultName(res) + ": " + resultMessage(res) + "."},
+				PartialFingerprints: map[string]string{
+					"ansibleRequirementsLint/v1": fingerprint(res),
+				},
+			}
+			if location, ok := sarifResultLocation(res); ok {
+				r.Locations = []sarifLocation{location}
+			}
+			run.Results = append(run.Results, r)
+		}
+	}
+}
+
+// sarifLevel converts a linter.Level to a SARIF result level.
+func sarifLevel(l linter.Level) string {
+	switch l {
+	case linter.LevelError:
+		return "error"
+	case linter.LevelWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifResultLocation returns the SARIF location of the given Result.
+// The region points to the version of the role or collection, when declared,
+// and to the start of its definition otherwise.
+func sarifResultLocation(res linter.Result) (sarifLocation, bool) {
+	var pos = res.Position()
+	if len(pos.File) == 0 {
+		return sarifLocation{}, false
+	}
+
+	var location = sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(pos.File)},
+		},
+	}
+	switch {
+	case !pos.Version.IsZero():
+		location.PhysicalLocation.Region = &sarifRegion{
+			StartLine:   pos.Version.Line,
+			StartColumn: pos.Version.Column,
+			EndLine:     pos.Version.EndLine,
+			EndColumn:   pos.Version.EndColumn,
+		}
+	case pos.Line != 0:
+		location.PhysicalLocation.Region = &sarifRegion{
+			StartLine:   pos.Line,
+			StartColumn: pos.Column,
+		}
+	}
+	return location, true
+}
@@ -18,7 +18,7 @@ type TableWriter struct {
 // WriteUpdates write Linters results in ASCII table format to the given io.Writer
 func (t TableWriter) WriteUpdates(ctx context.Context, w io.Writer, input <-chan linter.Result) error {
 	table := tablewriter.NewWriter(w)
-	table.SetHeader([]string{"Name", "Current Version", "Latest Version", "Status"})
+	table.SetHeader([]string{"Location", "Name", "Current Version", "Latest Version", "Status"})
 	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
 	table.SetCenterSeparator("|")
 	defer table.Render()
@@ -33,31 +33,38 @@ func (t TableWriter) WriteUpdates(ctx context.Context, w io.Writer, input <-chan
 				return nil
 			}
 
-			// get the role name and the Update metadata
-			var roleName = roleName(res.Role)
+			// get the role or collection name, version and the Update metadata
+			var location = resultLocation(res)
+			var name = resultName(res)
+			var version = resultVersion(res)
 			var meta = metadataToUpdate(res)
 
 			// print the text formatted message
 			if res.Level != linter.LevelInfo || t.Verbose {
 				switch {
-				case errors.IsRoleVersionNotFoundError(res.Err) && res.Role.Version != "":
+				case isVersionNotFoundError(res.Err) && version != "":
 					// we cannot determine whether there is any update cause we haven't found
 					// the version among the one available for the role
-					table.Append([]string{roleName, res.Role.Version, meta.ToVersion, "Update"})
-				case errors.IsRoleVersionNotFoundError(res.Err):
+					table.Append([]string{location, name, version, meta.ToVersion, "Update"})
+				case isVersionNotFoundError(res.Err):
 					// the user has not defined any version for the role
-					table.Append([]string{roleName, "-", meta.ToVersion, "Update"})
+					table.Append([]string{location, name, "-", meta.ToVersion, "Update"})
 				case errors.IsRoleNotFoundError(res.Err):
-					table.Append([]string{roleName, "-", "-", "Role Not Found"})
+					table.Append([]string{location, name, "-", "-", "Role Not Found"})
+				case errors.IsCollectionNotFoundError(res.Err):
+					table.Append([]string{location, name, "-", "-", "Collection Not Found"})
 				case res.Err != nil:
 					// there have been an error fetching for the version
-					table.Append([]string{roleName, "-", "-", fmt.Sprintf("Error: %v", res.Err)})
+					table.Append([]string{location, name, "-", "-", fmt.Sprintf("Error: %v", res.Err)})
+				case version == "":
+					// the role is installed at the latest version
+					table.Append([]string{location, name, "-", meta.ToVersion, "Ok"})
 				case meta.IsUpdate:
 					// there is an update for the role
-					table.Append([]string{roleName, res.Role.Version, meta.ToVersion, "Update"})
+					table.Append([]string{location, name, version, meta.ToVersion, "Update"})
 				default:
 					// the role is at the latest version
-					table.Append([]string{roleName, res.Role.Version, meta.ToVersion, "Ok"})
+					table.Append([]string{location, name, version, meta.ToVersion, "Ok"})
 				}
 			}
 		}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 
-	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
 	"github.com/atosatto/ansible-requirements-lint/pkg/linter"
 	"github.com/fatih/color"
 )
@@ -34,10 +33,6 @@ func (t TextWriter) WriteUpdates(ctx context.Context, w io.Writer, results <-cha
 				return nil
 			}
 
-			// get the role name and the Update metadata
-			var roleName = roleName(res.Role)
-			var meta = metadataToUpdate(res)
-
 			if res.Level != linter.LevelInfo || t.Verbose {
 
 				// print the Linter level
@@ -50,19 +45,13 @@ func (t TextWriter) WriteUpdates(ctx context.Context, w io.Writer, results <-cha
 					color.New(color.Bold, color.FgHiCyan).Fprintf(w, "INFO: ")
 				}
 
-				// print the Linter result
-				switch {
-				case errors.IsRoleVersionNotFoundError(res.Err) && res.Role.Version != "":
-					fmt.Fprintf(w, "%s: unable to find %s between the available versions for the role, tag a new release or use %s.\n", roleName, res.Role.Version, meta.ToVersion)
-				case errors.IsRoleVersionNotFoundError(res.Err):
-					fmt.Fprintf(w, "%s: no version specified for the role, pin it to version %s to avoid not explicit dependencies.\n", roleName, meta.ToVersion)
-				case res.Err != nil:
-					fmt.Fprintf(w, "%s: %v.\n", roleName, res.Err)
-				case meta.IsUpdate:
-					fmt.Fprintf(w, "%s: role not at the latest version, upgrade from %s to %s.\n", roleName, res.Role.Version, meta.ToVersion)
-				default:
-					fmt.Fprintf(w, "%s: %s is the latest version for the role, no update needed.\n", roleName, res.Role.Version)
+				// print the location of the role or collection
+				if location := resultLocation(res); len(location) != 0 {
+					fmt.Fprintf(w, "%s: ", location)
 				}
+
+				// print the Linter result
+				fmt.Fprintf(w, "%s: %s.\n", resultName(res), resultMessage(res))
 			}
 		}
 	}
@@ -1,6 +1,10 @@
 package writer
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
 	"github.com/atosatto/ansible-requirements-lint/pkg/linter"
 	"github.com/atosatto/ansible-requirements-lint/pkg/types"
 )
@@ -15,6 +19,184 @@ func roleName(role types.Role) string {
 	return role.Name
 }
 
+// isCollection checks whether the given Result
+// refers to a Collection rather than to a Role.
+func isCollection(res linter.Result) bool {
+	return len(res.Collection.Name) != 0
+}
+
+// resultName returns the name of the Role or
+// Collection the given Result refers to.
+func resultName(res linter.Result) string {
+	if isCollection(res) {
+		return res.Collection.Name
+	}
+	return roleName(res.Role)
+}
+
+// resultKind returns a human readable description
+// of the kind of dependency the given Result refers to.
+func resultKind(res linter.Result) string {
+	if isCollection(res) {
+		return "collection"
+	}
+	return "role"
+}
+
+// resultVersion returns the version of the Role or
+// Collection the given Result refers to.
+func resultVersion(res linter.Result) string {
+	if isCollection(res) {
+		return res.Collection.Version
+	}
+	return res.Role.Version
+}
+
+// resultLocation returns the location of the definition
+// of the role or collection the given Result refers to,
+// in the file:line format. An empty string is returned
+// when the location is unknown.
+func resultLocation(res linter.Result) string {
+	var pos = res.Position()
+	switch {
+	case len(pos.File) == 0:
+		return ""
+	case pos.Line == 0:
+		return pos.File
+	default:
+		return fmt.Sprintf("%s:%d", pos.File, pos.Line)
+	}
+}
+
+// resultMessage returns a human readable
+// description of the given Result.
+func resultMessage(res linter.Result) string {
+	var msg = updateMessage(res)
+	if stale := metadataToUpdate(res).StaleAsOf; !stale.IsZero() {
+		msg = fmt.Sprintf("%s (stale as of %s)", msg, stale.Format("2006-01-02 15:04 MST"))
+	}
+	if ignored := metadataToUpdate(res).IgnoredTags; len(ignored) != 0 {
+		msg = fmt.Sprintf("%s (ignored tags which are not versions: %s)", msg, ignoredTags(ignored))
+	}
+	if via := resultVia(res); len(via) != 0 {
+		msg = fmt.Sprintf("%s (required via %s)", msg, strings.Join(via, " > "))
+	}
+	return msg
+}
+
+// maxIgnoredTags is the maximum number of
+// ignored tags listed in the Results messages.
+const maxIgnoredTags = 3
+
+// ignoredTags returns the list of the given ignored tags,
+// truncated to maxIgnoredTags with the number of the others.
+func ignoredTags(tags []string) string {
+	if len(tags) <= maxIgnoredTags {
+		return strings.Join(tags, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(tags[:maxIgnoredTags], ", "), len(tags)-maxIgnoredTags)
+}
+
+// resultVia returns the chain of roles, in the name@version
+// format, through which the role the given Result refers to
+// has been pulled in as a transitive dependency.
+func resultVia(res linter.Result) []string {
+	if isCollection(res) {
+		return nil
+	}
+	var via []string
+	for _, r := range res.Role.Via {
+		if len(r.Version) == 0 {
+			via = append(via, roleName(r))
+		} else {
+			via = append(via, roleName(r)+"@"+r.Version)
+		}
+	}
+	return via
+}
+
+// updateMessage returns a human readable description
+// of the Update held by the given Result.
+func updateMessage(res linter.Result) string {
+	var kind = resultKind(res)
+	var version = resultVersion(res)
+	var meta = metadataToUpdate(res)
+
+	switch {
+	case isVersionNotFoundError(res.Err) && version != "" && meta.ToVersion == "":
+		return fmt.Sprintf("unable to find %s between the available versions for the %s, tag a new release", version, kind)
+	case isVersionNotFoundError(res.Err) && version != "":
+		return fmt.Sprintf("unable to find %s between the available versions for the %s, tag a new release or use %s", version, kind, meta.ToVersion)
+	case isVersionNotFoundError(res.Err):
+		return fmt.Sprintf("no version specified for the %s, pin it to version %s to avoid not explicit dependencies", kind, meta.ToVersion)
+	case res.Err != nil:
+		return res.Err.Error()
+	case version == "":
+		return fmt.Sprintf("no version specified for the %s, the latest version is %s", kind, meta.ToVersion)
+	case meta.IsUpdate && isLatestVersion(meta):
+		return fmt.Sprintf("%s not at the latest version, upgrade from %s to %s", kind, version, meta.ToVersion)
+	case meta.IsUpdate:
+		return fmt.Sprintf("%s not at the latest allowed version, upgrade from %s to %s (latest version %s)", kind, version, meta.ToVersion, meta.LatestVersion)
+	case isLatestVersion(meta):
+		return fmt.Sprintf("%s is the latest version for the %s, no update needed", meta.ToVersion, kind)
+	default:
+		return fmt.Sprintf("%s is the latest allowed version for the %s, no update needed (latest version %s)", meta.ToVersion, kind, meta.LatestVersion)
+	}
+}
+
+// isLatestVersion checks whether the version the Update
+// refers to is also the latest version overall, or the
+// latest version overall is unknown.
+func isLatestVersion(meta linter.Update) bool {
+	return len(meta.LatestVersion) == 0 || meta.LatestVersion == meta.ToVersion
+}
+
+// errorKind returns a short identifier of the kind of
+// error reported by the given Result, or an empty
+// string if the Result does not hold any error.
+func errorKind(res linter.Result) string {
+	switch {
+	case res.Err == nil:
+		return ""
+	case isVersionNotFoundError(res.Err):
+		return "version-not-found"
+	case errors.IsRoleNotFoundError(res.Err):
+		return "role-not-found"
+	case errors.IsCollectionNotFoundError(res.Err):
+		return "collection-not-found"
+	case errors.IsUnknownScmError(res.Err):
+		return "unknown-scm"
+	case errors.IsUnknownCollectionTypeError(res.Err):
+		return "unknown-collection-type"
+	case errors.IsInvalidRoleNameError(res.Err):
+		return "invalid-role-name"
+	case errors.IsNotCachedError(res.Err):
+		return "not-cached"
+	case errors.IsDependencyConflictError(res.Err):
+		return "dependency-conflict"
+	case errors.IsDependenciesNotResolvedError(res.Err):
+		return "dependencies-not-resolved"
+	case errors.IsNotLockedError(res.Err):
+		return "not-locked"
+	case errors.IsLockMismatchError(res.Err):
+		return "lock-mismatch"
+	case errors.IsTagMovedError(res.Err):
+		return "tag-moved"
+	case errors.IsUnpinnedVersionError(res.Err):
+		return "unpinned-version"
+	case errors.IsNoReleasesError(res.Err):
+		return "no-releases"
+	default:
+		return "error"
+	}
+}
+
+// isVersionNotFoundError checks whether err is either
+// a RoleVersionNotFoundError or a CollectionVersionNotFoundError.
+func isVersionNotFoundError(err error) bool {
+	return errors.IsRoleVersionNotFoundError(err) || errors.IsCollectionVersionNotFoundError(err)
+}
+
 // metadataToUpdate converts the metadata of the given
 // Result to Update. If the metadata are not an Update
 // or are nil, an empty Update struct will be returned.
@@ -0,0 +1,383 @@
+package writer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/atosatto/ansible-requirements-lint/pkg/errors"
+	"github.com/atosatto/ansible-requirements-lint/pkg/linter"
+	"github.com/atosatto/ansible-requirements-lint/pkg/types"
+)
+
+// testResults returns a set of Linter results
+// to be used to test the writers.
+func testResults() []linter.Result {
+	var role = types.Role{
+		Name:     "test.ansible-requirements-lint",
+		Version:  "v1.0.0",
+		Position: types.Position{File: "requirements.yml", Line: 3, Column: 3},
+	}
+	var notFound = types.Role{
+		Name:     "test.ansible-requirements-lint-notfound",
+		Scm:      "git",
+		Source:   "https://github.com/test/ansible-requirements-lint-notfound",
+		Version:  "v1.0.0",
+		Position: types.Position{File: "requirements.yml", Line: 6, Column: 3},
+	}
+	var collection = types.Collection{
+		Name:     "test.ansible_requirements_lint",
+		Version:  "1.1.0",
+		Type:     types.CollectionTypeGalaxy,
+		Position: types.Position{File: "requirements.yml", Line: 11, Column: 3},
+	}
+
+	return []linter.Result{
+		{
+			Role:     role,
+			Level:    linter.LevelWarning,
+			Metadata: linter.Update{FromVersion: "v1.0.0", ToVersion: "v1.1.0", IsUpdate: true},
+		},
+		{
+			Role:  notFound,
+			Level: linter.LevelError,
+			Err:   errors.NewRoleNotFoundError(notFound, "github.com"),
+		},
+		{
+			Collection: collection,
+			Level:      linter.LevelInfo,
+			Metadata:   linter.Update{FromVersion: "1.1.0", ToVersion: "1.1.0"},
+		},
+	}
+}
+
+// writeResults writes the testResults with the given Writer.
+func writeResults(t *testing.T, w Writer) string {
+	var out bytes.Buffer
+	var input = make(chan linter.Result)
+	go func() {
+		for _, res := range testResults() {
+			input <- res
+		}
+		close(input)
+	}()
+	if err := w.WriteUpdates(context.Background(), &out, input); err != nil {
+		t.Fatalf("expected no error, obtained %+v", err)
+	}
+	return out.String()
+}
+
+func TestJSONWriter(t *testing.T) {
+	var doc struct {
+		Results []jsonResult `json:"results"`
+		Summary jsonSummary  `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(writeResults(t, JSONWriter{})), &doc); err != nil {
+		t.Fatalf("unable to decode the JSON output: %v", err)
+	}
+
+	expected := []jsonResult{
+		{
+			Kind:            "role",
+			Name:            "test.ansible-requirements-lint",
+			File:            "requirements.yml",
+			Line:            3,
+			Column:          3,
+			CurrentVersion:  "v1.0.0",
+			LatestVersion:   "v1.1.0",
+			LatestAllowed:   "v1.1.0",
+			UpdateAvailable: true,
+			Level:           "WARN",
+			Message:         "role not at the latest version, upgrade from v1.0.0 to v1.1.0",
+		},
+		{
+			Kind:           "role",
+			Name:           "test.ansible-requirements-lint-notfound",
+			Source:         "https://github.com/test/ansible-requirements-lint-notfound",
+			Scm:            "git",
+			File:           "requirements.yml",
+			Line:           6,
+			Column:         3,
+			CurrentVersion: "v1.0.0",
+			Level:          "ERR",
+			ErrorKind:      "role-not-found",
+			Message:        "unable to find role test.ansible-requirements-lint-notfound on github.com",
+		},
+		{
+			Kind:           "collection",
+			Name:           "test.ansible_requirements_lint",
+			CollectionType: "galaxy",
+			File:           "requirements.yml",
+			Line:           11,
+			Column:         3,
+			CurrentVersion: "1.1.0",
+			LatestVersion:  "1.1.0",
+			LatestAllowed:  "1.1.0",
+			Level:          "INFO",
+			Message:        "1.1.0 is the latest version for the collection, no update needed",
+		},
+	}
+	if len(doc.Results) != len(expected) {
+		t.Fatalf("expecting %d results, obtained %d", len(expected), len(doc.Results))
+	}
+	for i := range expected {
+		if !reflect.DeepEqual(expected[i], doc.Results[i]) {
+			t.Errorf("expecting result %+v, obtained %+v", expected[i], doc.Results[i])
+		}
+	}
+
+	expectedSummary := jsonSummary{Total: 3, Info: 1, Warnings: 1, Errors: 1, Updates: 1}
+	if expectedSummary != doc.Summary {
+		t.Errorf("expecting summary %+v, obtained %+v", expectedSummary, doc.Summary)
+	}
+}
+
+func TestStaleResults(t *testing.T) {
+	var res = testResults()[0]
+	var update = res.Metadata.(linter.Update)
+	update.StaleAsOf = time.Date(2020, 3, 1, 10, 30, 0, 0, time.UTC)
+	res.Metadata = update
+
+	expected := "role not at the latest version, upgrade from v1.0.0 to v1.1.0 (stale as of 2020-03-01 10:30 UTC)"
+	if msg := resultMessage(res); msg != expected {
+		t.Errorf("expected message %q, obtained %q", expected, msg)
+	}
+	if stale := newJSONResult(res).StaleAsOf; stale != "2020-03-01T10:30:00Z" {
+		t.Errorf("expected stale_as_of 2020-03-01T10:30:00Z, obtained %q", stale)
+	}
+}
+
+func TestPolicyResults(t *testing.T) {
+	var res = testResults()[0]
+	var update = res.Metadata.(linter.Update)
+	update.LatestVersion = "v2.0.0"
+	res.Metadata = update
+
+	expected := "role not at the latest allowed version, upgrade from v1.0.0 to v1.1.0 (latest version v2.0.0)"
+	if msg := resultMessage(res); msg != expected {
+		t.Errorf("expected message %q, obtained %q", expected, msg)
+	}
+	if r := newJSONResult(res); r.LatestVersion != "v2.0.0" || r.LatestAllowed != "v1.1.0" {
+		t.Errorf("expected latest_version v2.0.0 and latest_allowed_version v1.1.0, obtained %q and %q", r.LatestVersion, r.LatestAllowed)
+	}
+
+	res.Level = linter.LevelInfo
+	res.Metadata = linter.Update{FromVersion: "v1.0.0", ToVersion: "v1.0.0", LatestVersion: "v2.0.0"}
+	expected = "v1.0.0 is the latest allowed version for the role, no update needed (latest version v2.0.0)"
+	if msg := resultMessage(res); msg != expected {
+		t.Errorf("expected message %q, obtained %q", expected, msg)
+	}
+}
+
+func TestIgnoredTagsResults(t *testing.T) {
+	var res = testResults()[0]
+	var update = res.Metadata.(linter.Update)
+	update.IgnoredTags = []string{"stable", "latest", "release-2020", "nightly"}
+	res.Metadata = update
+
+	expected := "role not at the latest version, upgrade from v1.0.0 to v1.1.0 (ignored tags which are not versions: stable, latest, release-2020 and 1 more)"
+	if msg := resultMessage(res); msg != expected {
+		t.Errorf("expected message %q, obtained %q", expected, msg)
+	}
+	if ignored := newJSONResult(res).IgnoredTags; !reflect.DeepEqual(ignored, update.IgnoredTags) {
+		t.Errorf("expected the ignored tags in ignored_tags, obtained %v", ignored)
+	}
+
+	res.Err = errors.NewNoReleasesError("role", "test.ansible-requirements-lint", 0)
+	res.Metadata = linter.Update{FromVersion: "v1.0.0"}
+	if id := ruleID(res); id != "no-releases" {
+		t.Errorf("expected rule no-releases, obtained %s", id)
+	}
+}
+
+func TestTransitiveResults(t *testing.T) {
+	var res = testResults()[0]
+	res.Role.Via = []types.Role{{Name: "test.parent", Version: "v2.0.0"}, {Source: "https://github.com/test/ansible-middle"}}
+
+	expected := "role not at the latest version, upgrade from v1.0.0 to v1.1.0 (required via test.parent@v2.0.0 > https://github.com/test/ansible-middle)"
+	if msg := resultMessage(res); msg != expected {
+		t.Errorf("expected message %q, obtained %q", expected, msg)
+	}
+	if via := newJSONResult(res).Via; !reflect.DeepEqual(via, []string{"test.parent@v2.0.0", "https://github.com/test/ansible-middle"}) {
+		t.Errorf("expected the chain of roles in via, obtained %v", via)
+	}
+}
+
+func TestJSONLinesWriter(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(writeResults(t, JSONLinesWriter{})), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expecting 4 lines, obtained %d", len(lines))
+	}
+
+	for i, l := range lines {
+		var obj struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal([]byte(l), &obj); err != nil {
+			t.Fatalf("unable to decode line %d: %v", i, err)
+		}
+		var expected = "result"
+		if i == len(lines)-1 {
+			expected = "summary"
+		}
+		if obj.Type != expected {
+			t.Errorf("expecting line %d to be a %s, obtained %s", i, expected, obj.Type)
+		}
+	}
+}
+
+func TestSARIFWriter(t *testing.T) {
+	var log sarifLog
+	if err := json.Unmarshal([]byte(writeResults(t, SARIFWriter{Version: "1.0.0"})), &log); err != nil {
+		t.Fatalf("unable to decode the SARIF output: %v", err)
+	}
+
+	if log.Version != sarifVersion || len(log.Runs) != 1 {
+		t.Fatalf("expecting a SARIF %s log with a single run, obtained %+v", sarifVersion, log)
+	}
+
+	// results with LevelInfo are not reported
+	results := log.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("expecting 2 results, obtained %d", len(results))
+	}
+
+	expected := []struct {
+		ruleID string
+		level  string
+		region sarifRegion
+	}{
+		{ruleID: "update-available", level: "warning", region: sarifRegion{StartLine: 3, StartColumn: 3}},
+		{ruleID: "role-not-found", level: "error", region: sarifRegion{StartLine: 6, StartColumn: 3}},
+	}
+	for i, e := range expected {
+		r := results[i]
+		if r.RuleID != e.ruleID || r.Level != e.level {
+			t.Errorf("expecting rule %s with level %s, obtained rule %s with level %s", e.ruleID, e.level, r.RuleID, r.Level)
+		}
+		if rules[r.RuleIndex].ID != r.RuleID {
+			t.Errorf("rule index %d does not match rule %s", r.RuleIndex, r.RuleID)
+		}
+		if len(r.Locations) != 1 || *r.Locations[0].PhysicalLocation.Region != e.region {
+			t.Errorf("expecting region %+v, obtained locations %+v", e.region, r.Locations)
+		}
+	}
+}
+
+func TestJUnitWriter(t *testing.T) {
+	var doc junitTestSuites
+	if err := xml.Unmarshal([]byte(writeResults(t, JUnitWriter{})), &doc); err != nil {
+		t.Fatalf("unable to decode the JUnit output: %v", err)
+	}
+
+	if doc.Tests != 3 || doc.Failures != 2 || len(doc.TestSuites) != 1 {
+		t.Fatalf("expecting a single testsuite with 3 tests and 2 failures, obtained %+v", doc)
+	}
+
+	suite := doc.TestSuites[0]
+	if suite.Name != "requirements.yml" || len(suite.TestCases) != 3 {
+		t.Fatalf("expecting the requirements.yml testsuite with 3 testcases, obtained %+v", suite)
+	}
+
+	expected := []*junitFailure{
+		{Message: "role not at the latest version, upgrade from v1.0.0 to v1.1.0", Type: "update-available"},
+		{Message: "unable to find role test.ansible-requirements-lint-notfound on github.com", Type: "role-not-found"},
+		nil,
+	}
+	for i, e := range expected {
+		f := suite.TestCases[i].Failure
+		switch {
+		case e == nil && f != nil:
+			t.Errorf("expecting testcase %d to pass, obtained failure %+v", i, f)
+		case e != nil && f == nil:
+			t.Errorf("expecting testcase %d to fail", i)
+		case e != nil && (e.Message != f.Message || e.Type != f.Type):
+			t.Errorf("expecting failure %+v, obtained %+v", e, f)
+		}
+	}
+}
+
+func TestCheckstyleWriter(t *testing.T) {
+	var report checkstyleReport
+	if err := xml.Unmarshal([]byte(writeResults(t, CheckstyleWriter{})), &report); err != nil {
+		t.Fatalf("unable to decode the Checkstyle output: %v", err)
+	}
+
+	if len(report.Files) != 1 || report.Files[0].Name != "requirements.yml" {
+		t.Fatalf("expecting a single requirements.yml file, obtained %+v", report.Files)
+	}
+
+	expected := []checkstyleError{
+		{Line: 3, Column: 3, Severity: "warning", Message: "test.ansible-requirements-lint: role not at the latest version, upgrade from v1.0.0 to v1.1.0.", Source: "ansible-requirements-lint.update-available"},
+		{Line: 6, Column: 3, Severity: "error", Message: "test.ansible-requirements-lint-notfound: unable to find role test.ansible-requirements-lint-notfound on github.com.", Source: "ansible-requirements-lint.role-not-found"},
+	}
+	if !reflect.DeepEqual(expected, report.Files[0].Errors) {
+		t.Errorf("expecting errors %+v, obtained %+v", expected, report.Files[0].Errors)
+	}
+}
+
+func TestCodeQualityWriter(t *testing.T) {
+	var issues []codeQualityIssue
+	if err := json.Unmarshal([]byte(writeResults(t, CodeQualityWriter{})), &issues); err != nil {
+		t.Fatalf("unable to decode the Code Quality output: %v", err)
+	}
+
+	if len(issues) != 2 {
+		t.Fatalf("expecting 2 issues, obtained %d", len(issues))
+	}
+	if issues[0].CheckName != "update-available" || issues[0].Severity != "minor" || issues[0].Location.Lines.Begin != 3 {
+		t.Errorf("unexpected issue %+v", issues[0])
+	}
+	if issues[0].Fingerprint == issues[1].Fingerprint {
+		t.Errorf("expecting different fingerprints for different issues")
+	}
+
+	// fingerprints do not depend on the version of the role
+	res := testResults()[0]
+	updated := res
+	updated.Role.Version = "v1.0.1"
+	updated.Role.Position.Line = 10
+	updated.Metadata = linter.Update{FromVersion: "v1.0.1", ToVersion: "v1.1.0", IsUpdate: true}
+	if fingerprint(res) != fingerprint(updated) {
+		t.Errorf("expecting fingerprints to be stable across role versions")
+	}
+
+	// fingerprints depend on the Linter reporting the result
+	updates := linter.Result{Role: res.Role, Level: linter.LevelError, Err: fmt.Errorf("unable to fetch the versions"), Linter: "updates"}
+	tags := updates
+	tags.Linter = "moved-tags"
+	if ruleID(updates) != ruleID(tags) || fingerprint(updates) == fingerprint(tags) {
+		t.Errorf("expecting different fingerprints for the results of different linters")
+	}
+}
+
+func TestGitHubWriter(t *testing.T) {
+	summary, err := ioutil.TempFile("", "ansible-requirements-lint")
+	if err != nil {
+		t.Fatalf("unable to create the step summary file: %v", err)
+	}
+	summary.Close()
+	defer os.Remove(summary.Name())
+
+	expected := "::warning file=requirements.yml,line=3,col=3,title=test.ansible-requirements-lint::role not at the latest version, upgrade from v1.0.0 to v1.1.0.\n" +
+		"::error file=requirements.yml,line=6,col=3,title=test.ansible-requirements-lint-notfound::unable to find role test.ansible-requirements-lint-notfound on github.com.\n"
+	if out := writeResults(t, GitHubWriter{StepSummary: summary.Name()}); out != expected {
+		t.Errorf("expecting workflow commands\n%s\nobtained\n%s", expected, out)
+	}
+
+	content, err := ioutil.ReadFile(summary.Name())
+	if err != nil {
+		t.Fatalf("unable to read the step summary file: %v", err)
+	}
+	if !strings.Contains(string(content), "| requirements.yml:3 | test.ansible-requirements-lint | v1.0.0 | v1.1.0 | WARN: role not at the latest version, upgrade from v1.0.0 to v1.1.0 |") {
+		t.Errorf("unexpected step summary\n%s", content)
+	}
+}